@@ -4,6 +4,7 @@ import (
 	"context"
 	"reflect"
 	"sync"
+	"time"
 
 	"github.com/codeduckcloud/trinity-go/core/logx"
 )
@@ -48,6 +49,18 @@ type Container struct {
 	// instance pool map
 	instanceMap            map[InstanceName]interface{}
 	instanceMapInitialized map[InstanceName]interface{}
+
+	observer Observer
+}
+
+// Observer observe the container, e.g. to collect the metrics
+type Observer interface {
+	// OnGet the instance got from the pool, only in the multi instance mode
+	OnGet(instanceName InstanceName)
+	// OnRelease the instance released to the pool, only in the multi instance mode
+	OnRelease(instanceName InstanceName)
+	// OnSelfCheck the self check of the instance finished
+	OnSelfCheck(instanceName InstanceName, duration time.Duration, err error)
 }
 
 // NewContainer get the new container instance
@@ -137,7 +150,7 @@ func (s *Container) InstanceDISelfCheck(ctx context.Context) error {
 	switch s.c.InstanceType {
 	case MultiInstance:
 		for k := range s.poolMap {
-			if err := s.DiSelfCheck(ctx, k); err != nil {
+			if err := s.observeSelfCheck(ctx, k); err != nil {
 				logx.FromCtx(ctx).Errorf("%-8v %-10v %-7v => %v, error: %v", "instance", "self-check", "failed", k, err)
 				return err
 			}
@@ -145,7 +158,7 @@ func (s *Container) InstanceDISelfCheck(ctx context.Context) error {
 		}
 	default:
 		for k := range s.instanceMap {
-			if err := s.DiSelfCheck(ctx, k); err != nil {
+			if err := s.observeSelfCheck(ctx, k); err != nil {
 				logx.FromCtx(ctx).Errorf("%-8v %-10v %-7v => %v, error: %v", "instance", "self-check", "failed", k, err)
 				return err
 			}
@@ -157,6 +170,15 @@ func (s *Container) InstanceDISelfCheck(ctx context.Context) error {
 	return nil
 }
 
+func (s *Container) observeSelfCheck(ctx context.Context, instanceName InstanceName) error {
+	start := time.Now()
+	err := s.DiSelfCheck(ctx, instanceName)
+	if s.observer != nil {
+		s.observer.OnSelfCheck(instanceName, time.Since(start), err)
+	}
+	return err
+}
+
 // InstanceDISelfCheck
 // get instance by instance name
 // injectingMap , the dependency instance, will inject the instance in injectingMap as priority
@@ -171,6 +193,9 @@ func (s *Container) GetInstance(ctx context.Context, instanceName InstanceName,
 			logx.FromCtx(ctx).Panicf("instance not exist in container => %v", instanceName)
 		}
 		service := pool.Get()
+		if s.observer != nil {
+			s.observer.OnGet(instanceName)
+		}
 		injectingMap[instanceName] = service
 		s.DiAllFields(ctx, service, injectingMap)
 		return service
@@ -202,6 +227,14 @@ func (s *Container) Release(ctx context.Context, instanceName InstanceName, inst
 	}
 	s.DiFree(ctx, instance)
 	instancePool.Put(instance)
+	if s.observer != nil {
+		s.observer.OnRelease(instanceName)
+	}
+}
+
+// SetObserver set the observer of the container
+func (s *Container) SetObserver(observer Observer) {
+	s.observer = observer
 }
 
 func (s *Container) getAutoWireTag(obj interface{}, index int) bool {
@@ -3,6 +3,7 @@ package container
 import (
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -182,3 +183,43 @@ func TestGetBoolTagFromContainer_NoTag(t *testing.T) {
 	assert.False(t, v)
 	assert.False(t, exist)
 }
+
+type recordObserver struct {
+	gets       []InstanceName
+	releases   []InstanceName
+	selfChecks map[InstanceName]error
+}
+
+func (o *recordObserver) OnGet(instanceName InstanceName) {
+	o.gets = append(o.gets, instanceName)
+}
+
+func (o *recordObserver) OnRelease(instanceName InstanceName) {
+	o.releases = append(o.releases, instanceName)
+}
+
+func (o *recordObserver) OnSelfCheck(instanceName InstanceName, duration time.Duration, err error) {
+	o.selfChecks[instanceName] = err
+}
+
+func TestContainer_Observer(t *testing.T) {
+	o := &recordObserver{selfChecks: make(map[InstanceName]error)}
+	c := NewContainer(Config{InstanceType: MultiInstance})
+	c.SetObserver(o)
+	c.RegisterMultiInstance(logWithCtx, "rep", &sync.Pool{New: func() interface{} { return &userRep{} }})
+	assert.NoError(t, c.InstanceDISelfCheck(logWithCtx))
+	assert.Contains(t, o.selfChecks, InstanceName("rep"))
+	assert.Nil(t, o.selfChecks["rep"])
+
+	rep := c.GetInstance(logWithCtx, "rep", make(map[InstanceName]interface{}))
+	c.Release(logWithCtx, "rep", rep)
+	c.Release(logWithCtx, "rep", &orderRepo{})
+	assert.Equal(t, []InstanceName{"rep"}, o.gets)
+	assert.Equal(t, []InstanceName{"rep"}, o.releases)
+
+	bad := NewContainer(Config{InstanceType: MultiInstance})
+	bad.SetObserver(o)
+	bad.RegisterMultiInstance(logWithCtx, "bad", &sync.Pool{New: func() interface{} { return testInjectErr1{} }})
+	assert.Error(t, bad.InstanceDISelfCheck(logWithCtx))
+	assert.Error(t, o.selfChecks["bad"])
+}
@@ -0,0 +1,171 @@
+package e
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+)
+
+type Category string
+
+const (
+	CategoryUnknown  Category = "UNKNOWN"
+	CategoryRequest  Category = "REQUEST"
+	CategoryAuth     Category = "AUTH"
+	CategoryBusiness Category = "BUSINESS"
+	CategoryInternal Category = "INTERNAL"
+)
+
+var (
+	_catalogMu sync.RWMutex
+	_catalog   = make(map[int]*Definition)
+)
+
+// Definition the error declared in the catalog
+type Definition struct {
+	code     int
+	status   int
+	category Category
+	template string
+}
+
+// Define declare the error in the catalog
+// the code should be unique, will panic if the code already defined
+// the template could contain the placeholder `{key}`, which is filled by With
+// e.g.
+//
+//	var ErrUserNotFound = e.Define(404002, http.StatusNotFound, e.CategoryBusiness, "user {id} not found")
+//	return e.ErrUserNotFound.With("id", 5)
+func Define(code int, status int, category Category, template string) *Definition {
+	_catalogMu.Lock()
+	defer _catalogMu.Unlock()
+	if d, exist := _catalog[code]; exist {
+		panic(fmt.Sprintf("error code %v already defined, template: %v", code, d.template))
+	}
+	d := &Definition{
+		code:     code,
+		status:   status,
+		category: category,
+		template: template,
+	}
+	_catalog[code] = d
+	return d
+}
+
+func (d *Definition) Code() int {
+	return d.code
+}
+
+func (d *Definition) Status() int {
+	return d.status
+}
+
+func (d *Definition) Category() Category {
+	return d.category
+}
+
+func (d *Definition) Template() string {
+	return d.template
+}
+
+// Error the Definition could be the target of errors.Is
+func (d *Definition) Error() string {
+	return fmt.Sprintf("error code: %v, error msg: %v", d.code, d.template)
+}
+
+// New new the error with the template as the message
+func (d *Definition) New(details ...string) WrapError {
+	return d.newError(d.template, nil, nil, details)
+}
+
+// WithMessage new the error with the customized message
+func (d *Definition) WithMessage(msg string, details ...string) WrapError {
+	return d.newError(msg, nil, nil, details)
+}
+
+// Wrap new the error with the template as the message and err as the cause
+// return nil if err is nil
+func (d *Definition) Wrap(err error, details ...string) WrapError {
+	if err == nil {
+		return nil
+	}
+	return d.newError(d.template, nil, err, details)
+}
+
+// With new the error with the template filled by the key value pairs
+// the unpaired key will be ignored
+func (d *Definition) With(keyValues ...interface{}) WrapError {
+	fields := make(map[string]interface{}, len(keyValues)/2)
+	pairs := make([]string, 0, len(keyValues))
+	for i := 0; i+1 < len(keyValues); i += 2 {
+		key := fmt.Sprint(keyValues[i])
+		fields[key] = keyValues[i+1]
+		pairs = append(pairs, "{"+key+"}", fmt.Sprint(keyValues[i+1]))
+	}
+	return d.newError(strings.NewReplacer(pairs...).Replace(d.template), fields, nil, nil)
+}
+
+func (d *Definition) newError(msg string, fields map[string]interface{}, cause error, details []string) WrapError {
+	pcs := make([]uintptr, maxStackDepth)
+	// skip runtime.Callers, newError and the Definition method
+	n := runtime.Callers(3, pcs)
+	return &wrapErrorImpl{
+		code:     d.code,
+		status:   d.status,
+		category: d.category,
+		msg:      msg,
+		fields:   fields,
+		details:  details,
+		cause:    cause,
+		stack:    pcs[:n],
+	}
+}
+
+func (d *Definition) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Code     int      `json:"code"`
+		Status   int      `json:"status"`
+		Category Category `json:"category"`
+		Message  string   `json:"message"`
+	}{
+		Code:     d.code,
+		Status:   d.status,
+		Category: d.category,
+		Message:  d.template,
+	})
+}
+
+// Catalog get all the defined errors order by the code
+func Catalog() []*Definition {
+	_catalogMu.RLock()
+	defer _catalogMu.RUnlock()
+	res := make([]*Definition, 0, len(_catalog))
+	for _, d := range _catalog {
+		res = append(res, d)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].code < res[j].code
+	})
+	return res
+}
+
+// WriteCatalog write the markdown table of the catalog for the api docs
+func WriteCatalog(w io.Writer) error {
+	if _, err := fmt.Fprintln(w, "| Code | HTTP Status | Category | Message |"); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(w, "| --- | --- | --- | --- |"); err != nil {
+		return err
+	}
+	for _, d := range Catalog() {
+		if _, err := fmt.Fprintf(w, "| %v | %v %v | %v | %v |\n", d.code, d.status, http.StatusText(d.status), d.category, strings.ReplaceAll(d.template, "|", "\\|")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
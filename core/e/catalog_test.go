@@ -0,0 +1,72 @@
+package e
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var errTestUserNotFound = Define(404901, http.StatusNotFound, CategoryBusiness, "user {id} not found in {tenant}")
+
+func TestDefine(t *testing.T) {
+	assert.Equal(t, 404901, errTestUserNotFound.Code())
+	assert.Equal(t, http.StatusNotFound, errTestUserNotFound.Status())
+	assert.Equal(t, CategoryBusiness, errTestUserNotFound.Category())
+	assert.Equal(t, "user {id} not found in {tenant}", errTestUserNotFound.Template())
+}
+
+func TestDefine_DuplicateCode(t *testing.T) {
+	assert.Panics(t, func() {
+		Define(404901, http.StatusNotFound, CategoryBusiness, "duplicated")
+	})
+}
+
+func TestDefinition_With(t *testing.T) {
+	err := errTestUserNotFound.With("id", 5, "tenant", "codeduck", "unpaired")
+	assert.Equal(t, 404901, err.Code())
+	assert.Equal(t, "user 5 not found in codeduck", err.Message())
+	assert.Equal(t, http.StatusNotFound, HTTPStatus(err))
+
+	impl := err.(*wrapErrorImpl)
+	assert.Equal(t, CategoryBusiness, impl.Category())
+	assert.Equal(t, map[string]interface{}{"id": 5, "tenant": "codeduck"}, impl.Fields())
+}
+
+func TestDefinition_New(t *testing.T) {
+	err := ErrValidation.New("detail1")
+	assert.Equal(t, ValidationError, err.Code())
+	assert.Equal(t, "validation failed", err.Message())
+	assert.Equal(t, []string{"detail1"}, err.Details())
+
+	err = ErrInvalidRequest.WithMessage("bad param", "detail2")
+	assert.Equal(t, InvalidRequestError, err.Code())
+	assert.Equal(t, "bad param", err.Message())
+	assert.Equal(t, []string{"detail2"}, err.Details())
+	assert.Equal(t, http.StatusBadRequest, HTTPStatus(err))
+	assert.Equal(t, CategoryRequest, err.(*wrapErrorImpl).Category())
+	assert.Equal(t, CategoryUnknown, New(1, "x").(*wrapErrorImpl).Category())
+}
+
+func TestCatalog(t *testing.T) {
+	catalog := Catalog()
+	codes := make([]int, 0, len(catalog))
+	for _, d := range catalog {
+		codes = append(codes, d.Code())
+	}
+	assert.Equal(t, []int{UnknownError, InvalidRequestError, UnauthorizedError, ForbiddenError, errTestUserNotFound.Code(), ValidationError, TooManyRequestsError, PanicError, TimeoutError}, codes)
+
+	b, err := json.Marshal(errTestUserNotFound)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"code":404901,"status":404,"category":"BUSINESS","message":"user {id} not found in {tenant}"}`, string(b))
+}
+
+func TestWriteCatalog(t *testing.T) {
+	buf := &bytes.Buffer{}
+	assert.NoError(t, WriteCatalog(buf))
+	assert.Contains(t, buf.String(), "| Code | HTTP Status | Category | Message |")
+	assert.Contains(t, buf.String(), "| 404901 | 404 Not Found | BUSINESS | user {id} not found in {tenant} |")
+	assert.Contains(t, buf.String(), "| 500001 | 500 Internal Server Error | INTERNAL | internal server error |")
+}
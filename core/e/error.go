@@ -1,6 +1,14 @@
 package e
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+)
+
+// the max depth of the stack captured when the error created
+const maxStackDepth = 32
 
 type WrapError interface {
 	Code() int
@@ -10,9 +18,14 @@ type WrapError interface {
 }
 
 type wrapErrorImpl struct {
-	code    int
-	msg     string
-	details []string
+	code     int
+	status   int
+	category Category
+	msg      string
+	fields   map[string]interface{}
+	details  []string
+	cause    error
+	stack    []uintptr
 }
 
 func (err *wrapErrorImpl) Code() int {
@@ -25,27 +38,147 @@ func (err *wrapErrorImpl) Message() string {
 func (err *wrapErrorImpl) Details() []string {
 	return err.details
 }
+
+// Category the category of the error
+// the error not created from the catalog is CategoryUnknown
+func (err *wrapErrorImpl) Category() Category {
+	if err.category == "" {
+		return CategoryUnknown
+	}
+	return err.category
+}
+
+// Fields the key value pairs filled in the message
+func (err *wrapErrorImpl) Fields() map[string]interface{} {
+	return err.fields
+}
+
+// HTTPStatus the http status of the error
+// if the status not set, will get the status from the error code
+func (err *wrapErrorImpl) HTTPStatus() int {
+	if err.status != 0 {
+		return err.status
+	}
+	return StatusFromCode(err.code)
+}
+
 func (err *wrapErrorImpl) Error() string {
+	if err.cause != nil {
+		return fmt.Sprintf("error code: %v, error msg: %v, error details: %v, cause: %v", err.code, err.msg, err.details, err.cause)
+	}
 	return fmt.Sprintf("error code: %v, error msg: %v, error details: %v", err.code, err.msg, err.details)
 }
+
+// Unwrap get the cause of the error, support errors.Is and errors.As
+func (err *wrapErrorImpl) Unwrap() error {
+	return err.cause
+}
+
+// Is the error is the target if the target is the *Definition with the same code
+// other targets are only matched by identity
+// e.g. errors.Is(err, e.ErrValidation)
+func (err *wrapErrorImpl) Is(target error) bool {
+	if d, ok := target.(*Definition); ok {
+		return d.code == err.code
+	}
+	return false
+}
+
+// Stack the stack trace captured when the error created
+func (err *wrapErrorImpl) Stack() string {
+	builder := strings.Builder{}
+	frames := runtime.CallersFrames(err.stack)
+	for {
+		frame, more := frames.Next()
+		if frame.Function != "" {
+			fmt.Fprintf(&builder, "%s\n\t%s:%d\n", frame.Function, frame.File, frame.Line)
+		}
+		if !more {
+			break
+		}
+	}
+	return builder.String()
+}
+
+// Format support %+v to print the error with the stack trace
+func (err *wrapErrorImpl) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		if s.Flag('+') {
+			fmt.Fprintf(s, "%s\n%s", err.Error(), err.Stack())
+			return
+		}
+		fmt.Fprint(s, err.Error())
+	case 's':
+		fmt.Fprint(s, err.Error())
+	case 'q':
+		fmt.Fprintf(s, "%q", err.Error())
+	default:
+		fmt.Fprint(s, err.Error())
+	}
+}
+
 func New(code int, msg string, details ...string) WrapError {
 	return &wrapErrorImpl{
 		code:    code,
 		msg:     msg,
 		details: details,
+		stack:   callers(),
+	}
+}
+
+// NewWithStatus new the error with the http status
+func NewWithStatus(status int, code int, msg string, details ...string) WrapError {
+	return &wrapErrorImpl{
+		code:    code,
+		status:  status,
+		msg:     msg,
+		details: details,
+		stack:   callers(),
+	}
+}
+
+// Wrap new the error with the cause
+// the cause could be get by errors.Unwrap, errors.Is and errors.As
+// return nil if err is nil
+func Wrap(err error, code int, msg string, details ...string) WrapError {
+	if err == nil {
+		return nil
+	}
+	return &wrapErrorImpl{
+		code:    code,
+		msg:     msg,
+		details: details,
+		cause:   err,
+		stack:   callers(),
 	}
 }
 
+// FromErr convert the err to WrapError
+// if there is WrapError in the error chain, will return the first one
+// otherwise will return the ErrUnknown with the err as the cause
 func FromErr(err error) WrapError {
 	if err == nil {
 		return nil
 	}
-	wrapErr, ok := err.(WrapError)
-	if !ok {
-		return &wrapErrorImpl{
-			code: UnknownError,
-			msg:  err.Error(),
-		}
+	var wrapErr WrapError
+	if errors.As(err, &wrapErr) {
+		return wrapErr
+	}
+	return &wrapErrorImpl{
+		code:     UnknownError,
+		status:   ErrUnknown.status,
+		category: ErrUnknown.category,
+		msg:      err.Error(),
+		cause:    err,
+		stack:    callers(),
 	}
-	return wrapErr
+}
+
+// callers capture the stack of the function creating the error
+func callers() []uintptr {
+	pcs := make([]uintptr, maxStackDepth)
+	// skip runtime.Callers, callers and the error constructor
+	n := runtime.Callers(3, pcs)
+	return pcs[:n]
 }
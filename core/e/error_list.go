@@ -1,3 +1,32 @@
 package e
 
-const UnknownError = 100001
+import "net/http"
+
+const (
+	UnknownError = 100001
+	// the request param parse failed, e.g. the wrong type of the query param
+	InvalidRequestError = 400001
+	// the token missing or invalid
+	UnauthorizedError = 401001
+	// the permission denied
+	ForbiddenError = 403001
+	// the request param validate failed
+	ValidationError = 422001
+	// the rate limit exceeded
+	TooManyRequestsError = 429001
+	// the panic recovered from the handler
+	PanicError = 500001
+	// the request not handled before the deadline
+	TimeoutError = 504001
+)
+
+var (
+	ErrUnknown         = Define(UnknownError, http.StatusInternalServerError, CategoryUnknown, "unknown error")
+	ErrInvalidRequest  = Define(InvalidRequestError, http.StatusBadRequest, CategoryRequest, "invalid request")
+	ErrUnauthorized    = Define(UnauthorizedError, http.StatusUnauthorized, CategoryAuth, "unauthorized")
+	ErrForbidden       = Define(ForbiddenError, http.StatusForbidden, CategoryAuth, "forbidden")
+	ErrValidation      = Define(ValidationError, http.StatusUnprocessableEntity, CategoryRequest, "validation failed")
+	ErrTooManyRequests = Define(TooManyRequestsError, http.StatusTooManyRequests, CategoryRequest, "too many requests")
+	ErrPanic           = Define(PanicError, http.StatusInternalServerError, CategoryInternal, "internal server error")
+	ErrTimeout         = Define(TimeoutError, http.StatusGatewayTimeout, CategoryInternal, "request timeout")
+)
@@ -2,6 +2,7 @@ package e
 
 import (
 	"errors"
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -39,3 +40,60 @@ func TestFromErr_WrapError(t *testing.T) {
 	wrapped := FromErr(original)
 	assert.Equal(t, original, wrapped)
 }
+
+func TestWrap(t *testing.T) {
+	cause := errors.New("sql: no rows")
+	err := Wrap(cause, 404001, "user not found", "id=1")
+	assert.Equal(t, 404001, err.Code())
+	assert.Equal(t, "user not found", err.Message())
+	assert.Equal(t, []string{"id=1"}, err.Details())
+	assert.Equal(t, "error code: 404001, error msg: user not found, error details: [id=1], cause: sql: no rows", err.Error())
+	assert.Equal(t, cause, errors.Unwrap(err))
+	assert.True(t, errors.Is(err, cause))
+	assert.Nil(t, Wrap(nil, 404001, "user not found"))
+}
+
+func TestWrapError_Is(t *testing.T) {
+	err := fmt.Errorf("load user: %w", ErrValidation.Wrap(errors.New("bad")))
+	assert.True(t, errors.Is(err, ErrValidation))
+	assert.False(t, errors.Is(err, New(ValidationError, "other message")))
+	wrapErr := New(ValidationError, "same error")
+	assert.True(t, errors.Is(fmt.Errorf("load user: %w", wrapErr), wrapErr))
+	assert.False(t, errors.Is(err, ErrPanic))
+	assert.False(t, errors.Is(err, errors.New("bad")))
+	assert.Nil(t, ErrValidation.Wrap(nil))
+}
+
+func TestWrapError_As(t *testing.T) {
+	err := fmt.Errorf("outer: %w", New(4001, "inner"))
+	var wrapErr WrapError
+	assert.True(t, errors.As(err, &wrapErr))
+	assert.Equal(t, 4001, wrapErr.Code())
+}
+
+func TestFromErr_Chain(t *testing.T) {
+	inner := New(errTestUserNotFound.Code(), "not found")
+	wrapped := FromErr(fmt.Errorf("service: %w", inner))
+	assert.Equal(t, inner, wrapped)
+	assert.Equal(t, 404, HTTPStatus(fmt.Errorf("service: %w", inner)))
+
+	cause := errors.New("boom")
+	unknown := FromErr(cause)
+	assert.Equal(t, UnknownError, unknown.Code())
+	assert.True(t, errors.Is(unknown, cause))
+}
+
+func TestWrapError_Stack(t *testing.T) {
+	err := New(1001, "with stack")
+	stack := err.(*wrapErrorImpl).Stack()
+	assert.Contains(t, stack, "TestWrapError_Stack")
+
+	stack = ErrValidation.New().(*wrapErrorImpl).Stack()
+	assert.Contains(t, stack, "TestWrapError_Stack")
+
+	assert.Contains(t, fmt.Sprintf("%+v", err), "TestWrapError_Stack")
+	assert.Equal(t, err.Error(), fmt.Sprintf("%v", err))
+	assert.Equal(t, err.Error(), fmt.Sprintf("%s", err))
+	assert.Equal(t, fmt.Sprintf("%q", err.Error()), fmt.Sprintf("%q", err))
+	assert.Equal(t, err.Error(), fmt.Sprintf("%d", err))
+}
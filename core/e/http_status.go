@@ -0,0 +1,71 @@
+package e
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+)
+
+// HTTPStatuser the error carrying the http status
+type HTTPStatuser interface {
+	HTTPStatus() int
+}
+
+type statusRange struct {
+	from   int
+	to     int
+	status int
+}
+
+var (
+	_statusRangesMu sync.RWMutex
+	_statusRanges   []statusRange
+)
+
+// RegisterStatusRange map the error code in [from, to] to the http status
+// the range registered later has the higher priority
+func RegisterStatusRange(from, to int, status int) {
+	_statusRangesMu.Lock()
+	defer _statusRangesMu.Unlock()
+	_statusRanges = append([]statusRange{{from: from, to: to, status: status}}, _statusRanges...)
+}
+
+// StatusFromCode get the http status from the error code
+// 1. the registered code range
+// 2. the status of the code defined in the catalog, see Define
+// return 500 if the code is not defined, the same as the unknown error
+func StatusFromCode(code int) int {
+	_statusRangesMu.RLock()
+	for _, r := range _statusRanges {
+		if code >= r.from && code <= r.to {
+			_statusRangesMu.RUnlock()
+			return r.status
+		}
+	}
+	_statusRangesMu.RUnlock()
+	_catalogMu.RLock()
+	defer _catalogMu.RUnlock()
+	if d, ok := _catalog[code]; ok {
+		return d.status
+	}
+	return http.StatusInternalServerError
+}
+
+// HTTPStatus get the http status of the first error in the error chain
+// 1. the HTTPStatus method of the error
+// 2. the status from the error code
+// return 0 if the status unknown
+func HTTPStatus(err error) int {
+	if err == nil {
+		return 0
+	}
+	var s HTTPStatuser
+	if errors.As(err, &s) {
+		return s.HTTPStatus()
+	}
+	var wrapErr WrapError
+	if errors.As(err, &wrapErr) {
+		return StatusFromCode(wrapErr.Code())
+	}
+	return 0
+}
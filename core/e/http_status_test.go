@@ -0,0 +1,41 @@
+package e
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatusFromCode(t *testing.T) {
+	assert.Equal(t, 404, StatusFromCode(errTestUserNotFound.Code()))
+	assert.Equal(t, 422, StatusFromCode(ValidationError))
+	assert.Equal(t, 500, StatusFromCode(PanicError))
+	assert.Equal(t, 500, StatusFromCode(UnknownError))
+	// the code not defined in the catalog is not inferred from the digits
+	assert.Equal(t, 500, StatusFromCode(404001))
+	assert.Equal(t, 500, StatusFromCode(4001))
+	assert.Equal(t, 500, StatusFromCode(200001))
+}
+
+func TestRegisterStatusRange(t *testing.T) {
+	defer func() {
+		_statusRanges = nil
+	}()
+	RegisterStatusRange(1000, 1999, 404)
+	RegisterStatusRange(1500, 1599, 409)
+	assert.Equal(t, 404, StatusFromCode(1000))
+	assert.Equal(t, 409, StatusFromCode(1500))
+	assert.Equal(t, 404, StatusFromCode(1999))
+	assert.Equal(t, 500, StatusFromCode(2000))
+	assert.Equal(t, 404, HTTPStatus(New(1001, "not found")))
+}
+
+func TestHTTPStatus(t *testing.T) {
+	assert.Equal(t, 0, HTTPStatus(nil))
+	assert.Equal(t, 0, HTTPStatus(errors.New("boom")))
+	assert.Equal(t, 401, HTTPStatus(New(401001, "unauthorized")))
+	assert.Equal(t, 409, HTTPStatus(NewWithStatus(409, 401001, "conflict")))
+	assert.Equal(t, 500, HTTPStatus(New(UnknownError, "unknown")))
+	assert.Equal(t, 500, HTTPStatus(New(404001, "not found")))
+}
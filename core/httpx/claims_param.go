@@ -0,0 +1,57 @@
+package httpx
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+
+	"github.com/codeduckcloud/trinity-go/core/e"
+	"github.com/codeduckcloud/trinity-go/core/jwtx"
+)
+
+const (
+	ClaimsTag = "claims_param"
+)
+
+var (
+	claimsType = reflect.TypeOf(jwtx.Claims{})
+)
+
+// setClaimsParam set the field from the claims verified by middleware.Jwt
+// `claims_param:""` => the whole jwtx.Claims
+// `claims_param:"sub,required"` => the claim converted into the field type, the path is supported as the body_param
+// the missing required claim is e.ErrUnauthorized
+func setClaimsParam(r *http.Request, field reflect.StructField, val *reflect.Value, claimsParam string) error {
+	tag := parseParamTag(claimsParam)
+	var claims jwtx.Claims
+	if r != nil {
+		claims, _ = jwtx.ClaimsFromCtx(r.Context())
+	}
+	if tag.name == "" {
+		if val.Type() != claimsType {
+			return fmt.Errorf("claims param %v should be jwtx.Claims, actual: %v", field.Name, val.Type())
+		}
+		if claims == nil && tag.required {
+			return e.ErrUnauthorized.New("claims missing")
+		}
+		val.Set(reflect.ValueOf(claims))
+		return nil
+	}
+	if _, ok := lookupBodyParam(claims, tag.name); !ok {
+		if tag.required {
+			return e.ErrUnauthorized.New(fmt.Sprintf("claim %v missing", tag.name))
+		}
+		_, err := setDefaultParam(field, val, "claims", tag, parseParamStyle(field, StyleSimple))
+		return err
+	}
+	value, err := bodyParamConverter(claims, tag.name, val.Type())
+	if err != nil {
+		return fmt.Errorf("claims param %v converted error, err: %v", field.Name, err)
+	}
+	if value == nil {
+		val.Set(reflect.Zero(val.Type()))
+		return nil
+	}
+	val.Set(reflect.ValueOf(value))
+	return nil
+}
@@ -0,0 +1,68 @@
+package httpx
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/codeduckcloud/trinity-go/core/e"
+	"github.com/codeduckcloud/trinity-go/core/jwtx"
+	"github.com/stretchr/testify/assert"
+)
+
+func newClaimsRequest(claims jwtx.Claims) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	if claims == nil {
+		return r
+	}
+	return r.WithContext(jwtx.WithClaims(r.Context(), claims))
+}
+
+func TestParse_ClaimsParam(t *testing.T) {
+	claims := jwtx.Claims{
+		"sub":    "user-1",
+		"tenant": map[string]interface{}{"id": json.Number("42")},
+		"roles":  []interface{}{"admin", "editor"},
+	}
+	req := &struct {
+		UserID   string      `claims_param:"sub,required"`
+		TenantID int64       `claims_param:"tenant.id"`
+		Roles    []string    `claims_param:"roles"`
+		Scope    string      `claims_param:"scope" default:"read"`
+		Email    *string     `claims_param:"email"`
+		Claims   jwtx.Claims `claims_param:""`
+	}{}
+	assert.Nil(t, Parse(newClaimsRequest(claims), req))
+	assert.Equal(t, "user-1", req.UserID)
+	assert.Equal(t, int64(42), req.TenantID)
+	assert.Equal(t, []string{"admin", "editor"}, req.Roles)
+	assert.Equal(t, "read", req.Scope)
+	assert.Nil(t, req.Email)
+	assert.Equal(t, claims, req.Claims)
+}
+
+func TestParse_ClaimsParamError(t *testing.T) {
+	err := Parse(newClaimsRequest(nil), &struct {
+		UserID string `claims_param:"sub,required"`
+	}{})
+	assert.True(t, errors.Is(err, e.ErrUnauthorized))
+	assert.Equal(t, http.StatusUnauthorized, e.HTTPStatus(parseParamErr(err)))
+
+	err = Parse(newClaimsRequest(nil), &struct {
+		Claims jwtx.Claims `claims_param:",required"`
+	}{})
+	assert.True(t, errors.Is(err, e.ErrUnauthorized))
+
+	err = Parse(newClaimsRequest(jwtx.Claims{"sub": "user-1"}), &struct {
+		UserID int `claims_param:"sub"`
+	}{})
+	assert.NotNil(t, err)
+	assert.Equal(t, http.StatusBadRequest, e.HTTPStatus(parseParamErr(err)))
+
+	err = Parse(newClaimsRequest(jwtx.Claims{}), &struct {
+		Claims map[string]string `claims_param:""`
+	}{})
+	assert.NotNil(t, err)
+}
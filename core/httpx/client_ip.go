@@ -0,0 +1,87 @@
+package httpx
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+const (
+	ForwardedForHeader = "X-Forwarded-For"
+	RealIPHeader       = "X-Real-IP"
+)
+
+var (
+	_trustedProxies []*net.IPNet
+)
+
+// SetTrustedProxies set the proxies allowed to set X-Forwarded-For and X-Real-IP, the ip or the CIDR, e.g. "10.0.0.0/8"
+// default: no proxy trusted, the headers are ignored
+func SetTrustedProxies(proxies ...string) error {
+	trusted := make([]*net.IPNet, 0, len(proxies))
+	for _, proxy := range proxies {
+		if !strings.Contains(proxy, "/") {
+			ip := net.ParseIP(proxy)
+			if ip == nil {
+				return fmt.Errorf("invalid trusted proxy %v", proxy)
+			}
+			bits := 8 * net.IPv6len
+			if ip4 := ip.To4(); ip4 != nil {
+				ip, bits = ip4, 8*net.IPv4len
+			}
+			trusted = append(trusted, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, ipNet, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return fmt.Errorf("invalid trusted proxy %v, err: %w", proxy, err)
+		}
+		trusted = append(trusted, ipNet)
+	}
+	_trustedProxies = trusted
+	return nil
+}
+
+// ClientIP the ip of the client
+// the host of the remote address is used, unless the remote address is the trusted proxy, see SetTrustedProxies
+// for the trusted proxy, by the order
+// 1. the last ip of X-Forwarded-For which is not the trusted proxy
+// 2. X-Real-IP
+// 3. the host of the remote address
+func ClientIP(r *http.Request) string {
+	peer, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		peer = r.RemoteAddr
+	}
+	if !isTrustedProxy(peer) {
+		return peer
+	}
+	forwarded := strings.Split(strings.Join(r.Header.Values(ForwardedForHeader), ","), ",")
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		ip := strings.TrimSpace(forwarded[i])
+		if net.ParseIP(ip) == nil {
+			break
+		}
+		if !isTrustedProxy(ip) {
+			return ip
+		}
+	}
+	if ip := strings.TrimSpace(r.Header.Get(RealIPHeader)); net.ParseIP(ip) != nil {
+		return ip
+	}
+	return peer
+}
+
+func isTrustedProxy(host string) bool {
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	for _, ipNet := range _trustedProxies {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
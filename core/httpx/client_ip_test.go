@@ -0,0 +1,78 @@
+package httpx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientIP(t *testing.T) {
+	assert.NoError(t, SetTrustedProxies("10.0.0.0/8", "::1"))
+	defer SetTrustedProxies()
+	tests := []struct {
+		name       string
+		header     map[string]string
+		remoteAddr string
+		want       string
+	}{
+		{
+			name:       "remote address",
+			remoteAddr: "10.0.0.1:5678",
+			want:       "10.0.0.1",
+		},
+		{
+			name:       "forwarded for",
+			header:     map[string]string{ForwardedForHeader: "198.51.100.9, 203.0.113.1, 10.0.0.2"},
+			remoteAddr: "10.0.0.1:5678",
+			want:       "203.0.113.1",
+		},
+		{
+			name:       "real ip",
+			header:     map[string]string{RealIPHeader: "203.0.113.2"},
+			remoteAddr: "10.0.0.1:5678",
+			want:       "203.0.113.2",
+		},
+		{
+			name:       "untrusted peer",
+			header:     map[string]string{ForwardedForHeader: "203.0.113.1", RealIPHeader: "203.0.113.2"},
+			remoteAddr: "192.0.2.1:5678",
+			want:       "192.0.2.1",
+		},
+		{
+			name:       "invalid forwarded for",
+			header:     map[string]string{ForwardedForHeader: "unknown"},
+			remoteAddr: "[::1]:5678",
+			want:       "::1",
+		},
+		{
+			name:       "remote address without port",
+			remoteAddr: "10.0.0.1",
+			want:       "10.0.0.1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			for k, v := range tt.header {
+				r.Header.Set(k, v)
+			}
+			r.RemoteAddr = tt.remoteAddr
+			assert.Equal(t, tt.want, ClientIP(r))
+		})
+	}
+}
+
+func TestClientIP_NoTrustedProxy(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set(ForwardedForHeader, "203.0.113.1")
+	r.Header.Set(RealIPHeader, "203.0.113.2")
+	r.RemoteAddr = "10.0.0.1:5678"
+	assert.Equal(t, "10.0.0.1", ClientIP(r))
+}
+
+func TestSetTrustedProxies_Invalid(t *testing.T) {
+	assert.Error(t, SetTrustedProxies("proxy"))
+	assert.Error(t, SetTrustedProxies("10.0.0.0/33"))
+}
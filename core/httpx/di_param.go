@@ -6,11 +6,15 @@ import (
 	"fmt"
 	"net/http"
 	"reflect"
+	"strings"
+
+	"github.com/codeduckcloud/trinity-go/core/e"
+	"github.com/go-playground/validator/v10"
 )
 
 func DIParamHandler(handler interface{}) func(w http.ResponseWriter, r *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
-		r = r.WithContext(context.WithValue(r.Context(), HttpxContext, NewContext(r, 200)))
+		r = r.WithContext(context.WithValue(r.Context(), HttpxContext, NewContext(r, 0)))
 		handlerType := reflect.TypeOf(handler)
 		inParams, err := InvokeHandler(handlerType, r)
 		if err != nil {
@@ -18,34 +22,58 @@ func DIParamHandler(handler interface{}) func(w http.ResponseWriter, r *http.Req
 			return
 		}
 		responseValue := reflect.ValueOf(handler).Call(inParams)
-		switch len(responseValue) {
-		case 0:
-			HttpResponse(r.Context(), w, GetHTTPStatusCode(r.Context(), DefaultHttpSuccessCode), nil)
+		HandleResponse(r.Context(), w, responseValue)
+	}
+}
+
+// HandleResponse write the return values of the handler
+// the handler could return
+// 1. nothing
+// 2. result or error
+// 3. result and error
+// the result could be *Result to set the status, headers and cookies, or *File / io.Reader to stream the content
+// the nil pointer result will be responded as 204 No Content
+// the <-chan T result and the started EventStream will be responded as server-sent events
+// the *wsx.Conn param will be closed with the error returned
+func HandleResponse(ctx context.Context, w http.ResponseWriter, responseValue []reflect.Value) {
+	if conn := getWebSocket(ctx); conn != nil {
+		closeWebSocket(ctx, conn, responseValue)
+		return
+	}
+	if stream := getEventStream(ctx); stream != nil {
+		defer stream.close()
+		if stream.isStarted() {
+			stream.finish(responseValue)
 			return
-		case 1:
-			if err, ok := responseValue[0].Interface().(error); ok {
-				if err != nil {
-					HttpResponseErr(r.Context(), w, err)
-					return
-				}
+		}
+	}
+	switch len(responseValue) {
+	case 0:
+		HttpResponse(ctx, w, GetHTTPStatusCode(ctx, DefaultHttpSuccessCode), nil)
+		return
+	case 1:
+		if responseValue[0].Type().Implements(errorType) {
+			if err, _ := responseValue[0].Interface().(error); err != nil {
+				HttpResponseErr(ctx, w, err)
+				return
 			}
-			HttpResponse(r.Context(), w, GetHTTPStatusCode(r.Context(), DefaultHttpSuccessCode), responseValue[0].Interface())
+			HttpResponse(ctx, w, GetHTTPStatusCode(ctx, DefaultHttpSuccessCode), nil)
 			return
-		case 2:
-			if err, ok := responseValue[1].Interface().(error); ok {
-				if err != nil {
-					HttpResponseErr(r.Context(), w, err)
-					return
-				}
+		}
+		writeResult(ctx, w, responseValue[0].Interface())
+		return
+	case 2:
+		if err, ok := responseValue[1].Interface().(error); ok {
+			if err != nil {
+				HttpResponseErr(ctx, w, err)
+				return
 			}
-			HttpResponse(r.Context(), w, GetHTTPStatusCode(r.Context(), DefaultHttpSuccessCode), responseValue[0].Interface())
-			return
-		default:
-
-			HttpResponseErr(r.Context(), w, fmt.Errorf("wrong res type , first out should be response value , second out should be error "))
-			return
 		}
-
+		writeResult(ctx, w, responseValue[0].Interface())
+		return
+	default:
+		HttpResponseErr(ctx, w, fmt.Errorf("wrong res type , first out should be response value , second out should be error "))
+		return
 	}
 }
 
@@ -68,7 +96,7 @@ func InvokeHandler(handlerType reflect.Type, r *http.Request) ([]reflect.Value,
 		case reflect.Struct:
 			targetValue := reflect.New(inType).Interface()
 			if err := Parse(r, targetValue); err != nil {
-				return nil, err
+				return nil, parseParamErr(err)
 			}
 			InParams[i] = reflect.ValueOf(targetValue).Elem()
 		default:
@@ -85,12 +113,17 @@ func InvokeMethod(handlerType reflect.Type, r *http.Request, instance interface{
 	}
 	numsIn := HandlerNumsIn(handlerType)
 	InParams := make([]reflect.Value, numsIn)
+	wsIndex := -1
 	var i = 0
 	for i < numsIn {
 		inType := handlerType.In(i)
 		inKind := inType.Kind()
 		switch inKind {
 		case reflect.Interface:
+			if inType == eventStreamType {
+				InParams[i] = reflect.ValueOf(newContextEventStream(r.Context(), w))
+				break
+			}
 			if contextType.Implements(inType) {
 				InParams[i] = reflect.ValueOf(r.Context())
 				break
@@ -99,11 +132,11 @@ func InvokeMethod(handlerType reflect.Type, r *http.Request, instance interface{
 				InParams[i] = reflect.ValueOf(w)
 				break
 			}
-			return nil, errors.New("wrong handler , interface only support context and httpResponseWriter")
+			return nil, errors.New("wrong handler , interface only support context, httpResponseWriter and EventStream")
 		case reflect.Struct:
 			targetValue := reflect.New(inType).Interface()
 			if err := Parse(r, targetValue); err != nil {
-				return nil, fmt.Errorf("parse param err: %v", err)
+				return nil, parseParamErr(err)
 			}
 			InParams[i] = reflect.ValueOf(targetValue).Elem()
 		case reflect.Ptr:
@@ -111,13 +144,17 @@ func InvokeMethod(handlerType reflect.Type, r *http.Request, instance interface{
 				InParams[i] = reflect.ValueOf(r)
 				break
 			}
+			if inType == wsConnType {
+				wsIndex = i
+				break
+			}
 			if inType == reflect.TypeOf(instance) {
 				InParams[i] = reflect.ValueOf(instance)
 				break
 			}
 			targetValue := reflect.New(inType.Elem()).Interface()
 			if err := Parse(r, targetValue); err != nil {
-				return nil, fmt.Errorf("parse param err: %v", err)
+				return nil, parseParamErr(err)
 			}
 			InParams[i] = reflect.ValueOf(targetValue)
 		default:
@@ -125,9 +162,35 @@ func InvokeMethod(handlerType reflect.Type, r *http.Request, instance interface{
 		}
 		i++
 	}
+	if wsIndex >= 0 {
+		conn, err := upgradeWebSocket(w, r)
+		if err != nil {
+			return nil, err
+		}
+		InParams[wsIndex] = reflect.ValueOf(conn)
+	}
 	return InParams, nil
 }
 
+// IsLongLived check whether the handler holds the response open, e.g. to buffer or time out the response is not allowed
+// the handler with the EventStream or *wsx.Conn param, or the <-chan T result is long-lived
+func IsLongLived(handlerType reflect.Type) bool {
+	if !IsHandler(handlerType) {
+		return false
+	}
+	for i := 0; i < handlerType.NumIn(); i++ {
+		if inType := handlerType.In(i); inType == eventStreamType || inType == wsConnType {
+			return true
+		}
+	}
+	for i := 0; i < handlerType.NumOut(); i++ {
+		if handlerType.Out(i).Kind() == reflect.Chan {
+			return true
+		}
+	}
+	return false
+}
+
 func IsHandler(handlerType reflect.Type) bool {
 	return handlerType.Kind() == reflect.Func
 }
@@ -135,3 +198,38 @@ func IsHandler(handlerType reflect.Type) bool {
 func HandlerNumsIn(handlerType reflect.Type) int {
 	return handlerType.NumIn()
 }
+
+// parseParamErr wrap the Parse error with the error code
+// validation error => e.ErrValidation (422), the details are the failed fields
+// others => e.ErrInvalidRequest (400)
+// the Parse error is kept as the cause, which may contain the raw request
+// the WrapError returned by Parse, e.g. the missing claim, is kept as it is
+func parseParamErr(err error) error {
+	if _, ok := err.(e.WrapError); ok {
+		return err
+	}
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		details := make([]string, 0, len(validationErrs))
+		for _, fieldErr := range validationErrs {
+			details = append(details, validationDetail(fieldErr))
+		}
+		return e.ErrValidation.Wrap(err, details...)
+	}
+	return e.ErrInvalidRequest.Wrap(err)
+}
+
+// validationDetail the detail of the failed field without the struct type
+// the field is named by the tag name func, see RegisterTagNameFunc
+// e.g. `address.city failed on the 'required' tag`
+func validationDetail(fieldErr validator.FieldError) string {
+	name := fieldErr.Namespace()
+	if i := strings.IndexByte(name, '.'); i >= 0 {
+		name = name[i+1:]
+	}
+	tag := fieldErr.Tag()
+	if fieldErr.Param() != "" {
+		tag += "=" + fieldErr.Param()
+	}
+	return fmt.Sprintf("%v failed on the '%v' tag", name, tag)
+}
@@ -9,6 +9,8 @@ import (
 	"reflect"
 	"testing"
 
+	"github.com/codeduckcloud/trinity-go/core/e"
+	"github.com/codeduckcloud/trinity-go/core/wsx"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -65,7 +67,7 @@ func TestDIParamHandler_InvalidHandler_InvokeErr(t *testing.T) {
 	rr := httptest.NewRecorder()
 	r, _ := http.NewRequest(http.MethodGet, "http://example.com/", nil)
 	h(rr, r)
-	assert.Equal(t, DefaultHttpErrorCode, rr.Code)
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
 }
 
 func TestDIParamHandler_OneReturn_Err(t *testing.T) {
@@ -74,7 +76,7 @@ func TestDIParamHandler_OneReturn_Err(t *testing.T) {
 	rr := httptest.NewRecorder()
 	r, _ := http.NewRequest(http.MethodGet, "http://example.com/", nil)
 	h(rr, r)
-	assert.Equal(t, DefaultHttpErrorCode, rr.Code)
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
 
 	var resp Response
 	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
@@ -109,7 +111,7 @@ func TestDIParamHandler_TwoReturn_Err(t *testing.T) {
 	rr := httptest.NewRecorder()
 	r, _ := http.NewRequest(http.MethodGet, "http://example.com/", nil)
 	h(rr, r)
-	assert.Equal(t, DefaultHttpErrorCode, rr.Code)
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
 }
 
 func TestDIParamHandler_TwoReturn_OK(t *testing.T) {
@@ -127,7 +129,7 @@ func TestDIParamHandler_TooManyReturns(t *testing.T) {
 	rr := httptest.NewRecorder()
 	r, _ := http.NewRequest(http.MethodGet, "http://example.com/", nil)
 	h(rr, r)
-	assert.Equal(t, DefaultHttpErrorCode, rr.Code)
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
 }
 
 func TestInvokeMethod_NotFunc(t *testing.T) {
@@ -241,3 +243,28 @@ func TestInvokeHandler_StructParseError(t *testing.T) {
 	_, err := InvokeHandler(reflect.TypeOf(fn), r)
 	assert.Error(t, err)
 }
+
+func TestInvokeMethod_ParseErrorStatus(t *testing.T) {
+	fn := func(args struct {
+		Age int `query_param:"age" validate:"max=100"`
+	}) {
+	}
+	r, _ := http.NewRequest(http.MethodGet, "http://example.com/?age=abc", nil)
+	_, err := InvokeMethod(reflect.TypeOf(fn), r, nil, httptest.NewRecorder())
+	assert.Equal(t, e.InvalidRequestError, e.FromErr(err).Code())
+	assert.Equal(t, http.StatusBadRequest, e.HTTPStatus(err))
+
+	r, _ = http.NewRequest(http.MethodGet, "http://example.com/?age=101", nil)
+	_, err = InvokeMethod(reflect.TypeOf(fn), r, nil, httptest.NewRecorder())
+	assert.Equal(t, e.ValidationError, e.FromErr(err).Code())
+	assert.Equal(t, http.StatusUnprocessableEntity, e.HTTPStatus(err))
+	assert.Contains(t, err.Error(), "cause: httpx.Parse validate error")
+}
+
+func TestIsLongLived(t *testing.T) {
+	assert.True(t, IsLongLived(reflect.TypeOf(func(stream EventStream) error { return nil })))
+	assert.True(t, IsLongLived(reflect.TypeOf(func(conn *wsx.Conn) {})))
+	assert.True(t, IsLongLived(reflect.TypeOf(func(ctx context.Context) (<-chan int, error) { return nil, nil })))
+	assert.False(t, IsLongLived(reflect.TypeOf(func(ctx context.Context, r *http.Request) (string, error) { return "", nil })))
+	assert.False(t, IsLongLived(reflect.TypeOf(1)))
+}
@@ -0,0 +1,105 @@
+package httpx
+
+import (
+	"context"
+	"net/http"
+)
+
+const (
+	MimeOctetStream = "application/octet-stream"
+	MimeTextPlain   = "text/plain; charset=utf-8"
+)
+
+const (
+	EnvelopeContext HTTPContextKey = "HTTPX_ENVELOPE_KEY"
+)
+
+var (
+	_defaultEnvelope Envelope = ResponseEnvelope{}
+)
+
+// Envelope decide the format of the result and the error written to the response
+// the status and the error info are already resolved by HttpResponse and HttpResponseErr
+type Envelope interface {
+	WriteResult(ctx context.Context, w http.ResponseWriter, status int, res interface{})
+	WriteError(ctx context.Context, w http.ResponseWriter, status int, err *ErrorInfo)
+}
+
+// SetEnvelope set the envelope of the app
+// default: ResponseEnvelope
+func SetEnvelope(env Envelope) {
+	_defaultEnvelope = env
+}
+
+// WithEnvelope set the envelope in the ctx, which has higher priority than the app envelope
+func WithEnvelope(ctx context.Context, env Envelope) context.Context {
+	return context.WithValue(ctx, EnvelopeContext, env)
+}
+
+// GetEnvelope get the envelope from the ctx
+// if not set, return the app envelope
+func GetEnvelope(ctx context.Context) Envelope {
+	if env, ok := ctx.Value(EnvelopeContext).(Envelope); ok && env != nil {
+		return env
+	}
+	return _defaultEnvelope
+}
+
+// UseEnvelope the middleware to set the envelope of the route
+// e.g.
+//
+//	trinity.NewRequestMapping("GET", "/users/{id}", "GetUser", httpx.UseEnvelope(httpx.ProblemEnvelope{}))
+func UseEnvelope(env Envelope) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(WithEnvelope(r.Context(), env)))
+		})
+	}
+}
+
+// ResponseEnvelope the default envelope, wrap the result and the error with Response
+type ResponseEnvelope struct {
+}
+
+func (ResponseEnvelope) WriteResult(ctx context.Context, w http.ResponseWriter, status int, res interface{}) {
+	JsonResponse(w, status, &Response{
+		Status:  status,
+		Result:  res,
+		TraceID: getTraceID(ctx),
+	})
+}
+
+func (ResponseEnvelope) WriteError(ctx context.Context, w http.ResponseWriter, status int, err *ErrorInfo) {
+	JsonResponse(w, status, &Response{
+		Status:  status,
+		Error:   err,
+		TraceID: getTraceID(ctx),
+	})
+}
+
+// NoEnvelope respond the bare result without the Response wrapper
+// nil => only the status, []byte => application/octet-stream, string => text/plain, others => json
+// the error is responded as the bare ErrorInfo
+type NoEnvelope struct {
+}
+
+func (NoEnvelope) WriteResult(ctx context.Context, w http.ResponseWriter, status int, res interface{}) {
+	switch val := res.(type) {
+	case nil:
+		w.WriteHeader(status)
+	case []byte:
+		w.Header().Set(ContentTypeHeader, MimeOctetStream)
+		w.WriteHeader(status)
+		w.Write(val)
+	case string:
+		w.Header().Set(ContentTypeHeader, MimeTextPlain)
+		w.WriteHeader(status)
+		w.Write([]byte(val))
+	default:
+		JsonResponse(w, status, res)
+	}
+}
+
+func (NoEnvelope) WriteError(ctx context.Context, w http.ResponseWriter, status int, err *ErrorInfo) {
+	JsonResponse(w, status, err)
+}
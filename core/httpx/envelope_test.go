@@ -0,0 +1,123 @@
+package httpx
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/codeduckcloud/trinity-go/core/e"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetEnvelope(t *testing.T) {
+	assert.Equal(t, ResponseEnvelope{}, GetEnvelope(context.Background()))
+	ctx := WithEnvelope(context.Background(), ProblemEnvelope{})
+	assert.Equal(t, ProblemEnvelope{}, GetEnvelope(ctx))
+
+	original := _defaultEnvelope
+	SetEnvelope(ProblemEnvelope{TypeBaseURI: "https://example.com/errors/"})
+	defer SetEnvelope(original)
+	assert.Equal(t, ProblemEnvelope{TypeBaseURI: "https://example.com/errors/"}, GetEnvelope(context.Background()))
+}
+
+func TestProblemEnvelope_WriteError(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/users/1?debug=true", nil)
+	ctx := context.WithValue(r.Context(), HttpxContext, NewContext(r, 200))
+	ctx = WithEnvelope(ctx, ProblemEnvelope{})
+	w := httptest.NewRecorder()
+	HttpResponseErr(ctx, w, e.ErrValidation.New("name is required", "age must be positive"))
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Equal(t, MimeProblemJSON, w.Header().Get(ContentTypeHeader))
+	var problem ProblemDetails
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &problem))
+	assert.Equal(t, "urn:error:422001", problem.Type)
+	assert.Equal(t, e.ErrValidation.Template(), problem.Title)
+	assert.Equal(t, http.StatusUnprocessableEntity, problem.Status)
+	assert.Equal(t, "name is required; age must be positive", problem.Detail)
+	assert.Equal(t, "/users/1", problem.Instance)
+	assert.Equal(t, e.ValidationError, problem.Code)
+	assert.NotEmpty(t, problem.ErrorID)
+}
+
+func TestProblemEnvelope_TypeBaseURI(t *testing.T) {
+	ctx := WithEnvelope(context.Background(), ProblemEnvelope{TypeBaseURI: "https://example.com/errors/"})
+	w := httptest.NewRecorder()
+	HttpResponseErr(ctx, w, errors.New("boom"))
+	var problem ProblemDetails
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &problem))
+	assert.Equal(t, "https://example.com/errors/100001", problem.Type)
+	assert.Equal(t, http.StatusInternalServerError, problem.Status)
+	assert.Equal(t, "", problem.Instance)
+}
+
+func TestProblemEnvelope_WriteResult(t *testing.T) {
+	ctx := WithEnvelope(context.Background(), ProblemEnvelope{})
+	w := httptest.NewRecorder()
+	HttpResponse(ctx, w, http.StatusOK, "ok")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get(ContentTypeHeader))
+	assert.JSONEq(t, `{"status":200,"result":"ok"}`, w.Body.String())
+}
+
+func TestUseEnvelope(t *testing.T) {
+	handler := UseEnvelope(ProblemEnvelope{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		HttpResponseErr(r.Context(), w, e.ErrInvalidRequest.New())
+	}))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, MimeProblemJSON, w.Header().Get(ContentTypeHeader))
+}
+
+func TestNoEnvelope_WriteResult(t *testing.T) {
+	tests := []struct {
+		name            string
+		res             interface{}
+		wantContentType string
+		wantBody        string
+	}{
+		{name: "nil", res: nil, wantContentType: "", wantBody: ""},
+		{name: "bytes", res: []byte("raw"), wantContentType: MimeOctetStream, wantBody: "raw"},
+		{name: "string", res: "ok", wantContentType: MimeTextPlain, wantBody: "ok"},
+		{name: "struct", res: struct {
+			Status string `json:"status"`
+		}{Status: "up"}, wantContentType: "application/json", wantBody: `{"status":"up"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := WithEnvelope(context.Background(), NoEnvelope{})
+			w := httptest.NewRecorder()
+			HttpResponse(ctx, w, http.StatusCreated, tt.res)
+			assert.Equal(t, http.StatusCreated, w.Code)
+			assert.Equal(t, tt.wantContentType, w.Header().Get(ContentTypeHeader))
+			assert.Equal(t, tt.wantBody, w.Body.String())
+		})
+	}
+}
+
+func TestNoEnvelope_WriteError(t *testing.T) {
+	ctx := WithEnvelope(context.Background(), NoEnvelope{})
+	w := httptest.NewRecorder()
+	HttpResponseErr(ctx, w, e.ErrInvalidRequest.New("bad"))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	var info ErrorInfo
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &info))
+	assert.Equal(t, e.InvalidRequestError, info.Code)
+	assert.Equal(t, []string{"bad"}, info.Details)
+}
+
+func TestDIParamHandler_NoEnvelope(t *testing.T) {
+	h := UseEnvelope(NoEnvelope{})(http.HandlerFunc(DIParamHandler(func(ctx context.Context, args struct {
+		Name string `query_param:"name"`
+	}) (map[string]string, error) {
+		return map[string]string{"name": args.Name}, nil
+	})))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/?name=trinity", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"name":"trinity"}`, w.Body.String())
+}
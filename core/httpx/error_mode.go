@@ -0,0 +1,121 @@
+package httpx
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/codeduckcloud/trinity-go/core/e"
+	"github.com/codeduckcloud/trinity-go/core/logx"
+	"github.com/codeduckcloud/trinity-go/core/utils"
+)
+
+type ErrorMode string
+
+const (
+	// the error response contains the full message of the error and the cause
+	ErrorModeDevelopment ErrorMode = "DEVELOPMENT"
+	// the internal error response only contains the generic message and the error id
+	// the full error will be logged with the error id
+	ErrorModeProduction ErrorMode = "PRODUCTION"
+
+	// the message of the internal error in production mode
+	InternalErrorMessage = "internal server error"
+)
+
+var (
+	_errorMode = ErrorModeDevelopment
+)
+
+// SetErrorMode set how much error detail responded to the client
+// default: ErrorModeDevelopment
+func SetErrorMode(mode ErrorMode) {
+	_errorMode = mode
+}
+
+func GetErrorMode() ErrorMode {
+	return _errorMode
+}
+
+// isInternalError the internal error should not be exposed to the client
+// e.g. the unknown error, the panic
+func isInternalError(wrapErr e.WrapError, status int) bool {
+	return status >= 500 || wrapErr.Code() == e.UnknownError
+}
+
+// newErrorInfo build the error info responded to the client
+// production mode: the internal error will be replaced by the generic message
+// development mode: the message will contain the cause chain
+func newErrorInfo(wrapErr e.WrapError, status int, errorID string) *ErrorInfo {
+	if _errorMode == ErrorModeProduction {
+		if isInternalError(wrapErr, status) {
+			return &ErrorInfo{
+				Code:    wrapErr.Code(),
+				Message: InternalErrorMessage,
+				ErrorID: errorID,
+			}
+		}
+		return &ErrorInfo{
+			Code:    wrapErr.Code(),
+			Message: wrapErr.Message(),
+			Details: wrapErr.Details(),
+			ErrorID: errorID,
+		}
+	}
+	return &ErrorInfo{
+		Code:    wrapErr.Code(),
+		Message: verboseMessage(wrapErr),
+		Details: wrapErr.Details(),
+		ErrorID: errorID,
+	}
+}
+
+// verboseMessage the message with all the causes, e.g. `invalid request: key a not exist`
+func verboseMessage(wrapErr e.WrapError) string {
+	messages := []string{wrapErr.Message()}
+	cause := errors.Unwrap(wrapErr)
+	for cause != nil {
+		causeWrapErr, ok := cause.(e.WrapError)
+		if !ok {
+			if cause.Error() != messages[len(messages)-1] {
+				messages = append(messages, cause.Error())
+			}
+			break
+		}
+		messages = append(messages, causeWrapErr.Message())
+		cause = errors.Unwrap(cause)
+	}
+	return strings.Join(messages, ": ")
+}
+
+func newErrorID() string {
+	return strconv.FormatInt(utils.GetSnowflakeID(), 10)
+}
+
+// logError log the full error with the error id and the request info
+// the internal error will be logged in error level with the stack, the others in warn level
+func logError(ctx context.Context, wrapErr e.WrapError, status int, errorID string) {
+	logger, ok := logx.TryFromCtx(ctx)
+	if !ok {
+		return
+	}
+	fields := map[string]interface{}{
+		"error_id": errorID,
+		"code":     wrapErr.Code(),
+		"status":   status,
+	}
+	if val, ok := ctx.Value(HttpxContext).(*Context); ok && val != nil && val.r != nil {
+		fields["method"] = val.r.Method
+		fields["path"] = val.r.URL.Path
+	}
+	logger = logger.WithFields(fields)
+	if !isInternalError(wrapErr, status) {
+		logger.Warnf("http response error: %v", wrapErr.Error())
+		return
+	}
+	if s, ok := wrapErr.(interface{ Stack() string }); ok {
+		logger = logger.WithField("stack", s.Stack())
+	}
+	logger.Errorf("http response error: %v", wrapErr.Error())
+}
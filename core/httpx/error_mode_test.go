@@ -0,0 +1,151 @@
+package httpx
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/codeduckcloud/trinity-go/core/e"
+	"github.com/codeduckcloud/trinity-go/core/logx"
+	"github.com/stretchr/testify/assert"
+)
+
+type recordLogger struct {
+	logx.Logger
+	fields map[string]interface{}
+	buf    *bytes.Buffer
+}
+
+func newRecordLogger() *recordLogger {
+	return &recordLogger{
+		Logger: logx.NewLogrusLogger(),
+		fields: make(map[string]interface{}),
+		buf:    &bytes.Buffer{},
+	}
+}
+
+func (l *recordLogger) WithField(key string, value interface{}) logx.Logger {
+	l.fields[key] = value
+	return l
+}
+
+func (l *recordLogger) WithFields(fields map[string]interface{}) logx.Logger {
+	for k, v := range fields {
+		l.fields[k] = v
+	}
+	return l
+}
+
+func (l *recordLogger) Warnf(format string, args ...interface{}) {
+	fmt.Fprintf(l.buf, "WARN "+format, args...)
+}
+
+func (l *recordLogger) Errorf(format string, args ...interface{}) {
+	fmt.Fprintf(l.buf, "ERROR "+format, args...)
+}
+
+func withErrorMode(t *testing.T, mode ErrorMode) {
+	original := GetErrorMode()
+	SetErrorMode(mode)
+	t.Cleanup(func() {
+		SetErrorMode(original)
+	})
+}
+
+func TestHttpResponseErr_ProductionMode(t *testing.T) {
+	withErrorMode(t, ErrorModeProduction)
+	tests := []struct {
+		name        string
+		err         error
+		wantStatus  int
+		wantMessage string
+		wantDetails []string
+	}{
+		{
+			name:        "unknown error",
+			err:         errors.New("pq: relation users does not exist"),
+			wantStatus:  http.StatusInternalServerError,
+			wantMessage: InternalErrorMessage,
+		},
+		{
+			name:        "panic",
+			err:         e.ErrPanic.WithMessage("panic runtime error: index out of range"),
+			wantStatus:  http.StatusInternalServerError,
+			wantMessage: InternalErrorMessage,
+		},
+		{
+			name:        "parse error",
+			err:         parseParamErr(errors.New("param Body converted error, val : {\"password\":\"123\"}")),
+			wantStatus:  http.StatusBadRequest,
+			wantMessage: "invalid request",
+		},
+		{
+			name:        "business error",
+			err:         e.NewWithStatus(http.StatusNotFound, 404001, "user not found", "id=1"),
+			wantStatus:  http.StatusNotFound,
+			wantMessage: "user not found",
+			wantDetails: []string{"id=1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := newRecordLogger()
+			r := httptest.NewRequest(http.MethodPost, "/users", nil)
+			ctx := context.WithValue(logx.NewCtx(logger), HttpxContext, NewContext(r, 0))
+			rr := httptest.NewRecorder()
+			HttpResponseErr(ctx, rr, tt.err)
+			assert.Equal(t, tt.wantStatus, rr.Code)
+
+			var resp Response
+			assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			assert.Equal(t, tt.wantMessage, resp.Error.Message)
+			assert.Equal(t, tt.wantDetails, resp.Error.Details)
+			assert.NotEmpty(t, resp.Error.ErrorID)
+			assert.NotContains(t, rr.Body.String(), "password")
+			assert.NotContains(t, rr.Body.String(), "pq:")
+
+			assert.Equal(t, resp.Error.ErrorID, logger.fields["error_id"])
+			assert.Equal(t, http.MethodPost, logger.fields["method"])
+			assert.Equal(t, "/users", logger.fields["path"])
+			assert.Contains(t, logger.buf.String(), tt.err.Error())
+		})
+	}
+}
+
+func TestHttpResponseErr_DevelopmentMode(t *testing.T) {
+	withErrorMode(t, ErrorModeDevelopment)
+	rr := httptest.NewRecorder()
+	HttpResponseErr(context.Background(), rr, parseParamErr(errors.New("key a not exist")))
+	var resp Response
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	assert.Equal(t, "invalid request: key a not exist", resp.Error.Message)
+
+	rr = httptest.NewRecorder()
+	HttpResponseErr(context.Background(), rr, e.ErrPanic.WithMessage("panic boom"))
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	assert.Equal(t, "panic boom", resp.Error.Message)
+}
+
+func TestHttpResponseErr_LogLevel(t *testing.T) {
+	logger := newRecordLogger()
+	HttpResponseErr(logx.NewCtx(logger), httptest.NewRecorder(), e.ErrInvalidRequest.WithMessage("bad request"))
+	assert.Contains(t, logger.buf.String(), "WARN")
+	assert.Nil(t, logger.fields["stack"])
+
+	logger = newRecordLogger()
+	HttpResponseErr(logx.NewCtx(logger), httptest.NewRecorder(), e.ErrPanic.New())
+	assert.Contains(t, logger.buf.String(), "ERROR")
+	assert.Contains(t, logger.fields["stack"], "TestHttpResponseErr_LogLevel")
+}
+
+func TestVerboseMessage(t *testing.T) {
+	inner := e.Wrap(errors.New("connection refused"), 500002, "query failed")
+	outer := e.Wrap(inner, 500003, "load user failed")
+	assert.Equal(t, "load user failed: query failed: connection refused", verboseMessage(outer))
+	assert.Equal(t, "boom", verboseMessage(e.FromErr(errors.New("boom"))))
+}
@@ -0,0 +1,282 @@
+package httpx
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	MimeEventStream          = "text/event-stream"
+	CacheControlHeader       = "Cache-Control"
+	ConnectionHeader         = "Connection"
+	AccelBufferingHeader     = "X-Accel-Buffering"
+	DefaultHeartbeatInterval = 15 * time.Second
+	// the event name of the error sent after the stream started
+	ErrorEventName = "error"
+)
+
+var (
+	_heartbeatInterval = DefaultHeartbeatInterval
+)
+
+// SetHeartbeatInterval set the interval of the heartbeat comment sent to keep the stream alive
+// the heartbeat is disabled if the interval <= 0
+// default: DefaultHeartbeatInterval
+func SetHeartbeatInterval(interval time.Duration) {
+	_heartbeatInterval = interval
+}
+
+// Event the server-sent event
+type Event struct {
+	ID    string
+	Event string
+	// string and []byte are sent as is, others are encoded as json
+	Data interface{}
+	// the reconnection time of the client
+	Retry time.Duration
+}
+
+// EventStream the server-sent events stream injected into the handler
+// the stream is started by the first Send, before that the handler could still return the error as the normal response
+// after the handler returned, the error is sent as the `error` event
+//
+//	func (c *ProgressController) Watch(stream httpx.EventStream, args *WatchRequest) error {
+//		for progress := range c.progress(args.JobID) {
+//			if err := stream.SendData(progress); err != nil {
+//				return err
+//			}
+//		}
+//		return nil
+//	}
+type EventStream interface {
+	Send(event Event) error
+	SendData(data interface{}) error
+	// Context is done when the client disconnected
+	Context() context.Context
+}
+
+type eventStream struct {
+	ctx     context.Context
+	w       http.ResponseWriter
+	mu      sync.Mutex
+	started bool
+	closed  bool
+	stop    chan struct{}
+	wg      sync.WaitGroup
+}
+
+func newEventStream(ctx context.Context, w http.ResponseWriter) *eventStream {
+	return &eventStream{
+		ctx:  ctx,
+		w:    w,
+		stop: make(chan struct{}),
+	}
+}
+
+// newContextEventStream new the stream and bind it to the httpx context
+// so that HandleResponse could skip the envelope once the stream started
+func newContextEventStream(ctx context.Context, w http.ResponseWriter) *eventStream {
+	stream := newEventStream(ctx, w)
+	if val, ok := ctx.Value(HttpxContext).(*Context); ok && val != nil {
+		val.stream = stream
+	}
+	return stream
+}
+
+func getEventStream(ctx context.Context) *eventStream {
+	if val, ok := ctx.Value(HttpxContext).(*Context); ok && val != nil {
+		return val.stream
+	}
+	return nil
+}
+
+// IsStreaming check whether the response of the request is the started event stream
+func IsStreaming(ctx context.Context) bool {
+	stream := getEventStream(ctx)
+	return stream != nil && stream.isStarted()
+}
+
+// CloseEventStream stop the event stream of the request, it is called by HandleResponse
+// the raw handler, which skip HandleResponse, should close it before the handler returned
+func CloseEventStream(ctx context.Context) {
+	if stream := getEventStream(ctx); stream != nil {
+		stream.close()
+	}
+}
+
+func (s *eventStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *eventStream) SendData(data interface{}) error {
+	return s.Send(Event{Data: data})
+}
+
+func (s *eventStream) Send(event Event) error {
+	payload, err := encodeEvent(event)
+	if err != nil {
+		return err
+	}
+	return s.write(payload)
+}
+
+func (s *eventStream) write(payload []byte) error {
+	if err := s.ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return fmt.Errorf("event stream closed")
+	}
+	if !s.started {
+		s.start()
+	}
+	if _, err := s.w.Write(payload); err != nil {
+		return err
+	}
+	s.flush()
+	return nil
+}
+
+// start write the headers and start the heartbeat, must be called with the lock
+func (s *eventStream) start() {
+	s.started = true
+	header := s.w.Header()
+	header.Set(ContentTypeHeader, MimeEventStream)
+	header.Set(CacheControlHeader, "no-cache")
+	header.Set(ConnectionHeader, "keep-alive")
+	header.Set(AccelBufferingHeader, "no")
+	s.w.WriteHeader(http.StatusOK)
+	s.flush()
+	if _heartbeatInterval > 0 {
+		s.wg.Add(1)
+		go s.heartbeat(_heartbeatInterval)
+	}
+}
+
+func (s *eventStream) heartbeat(interval time.Duration) {
+	defer s.wg.Done()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			s.mu.Lock()
+			if !s.closed {
+				s.w.Write([]byte(": ping\n\n"))
+				s.flush()
+			}
+			s.mu.Unlock()
+		}
+	}
+}
+
+func (s *eventStream) flush() {
+	if flusher, ok := s.w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (s *eventStream) isStarted() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.started
+}
+
+// finish send the error returned by the handler as the error event
+func (s *eventStream) finish(responseValue []reflect.Value) {
+	for _, val := range responseValue {
+		if err, ok := val.Interface().(error); ok && err != nil {
+			_, info := resolveError(s.ctx, err)
+			s.Send(Event{Event: ErrorEventName, Data: info})
+		}
+	}
+}
+
+func (s *eventStream) close() {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return
+	}
+	s.closed = true
+	close(s.stop)
+	s.mu.Unlock()
+	s.wg.Wait()
+}
+
+// streamChannel send the values received from the channel as the events
+// until the channel closed or the client disconnected
+func streamChannel(ctx context.Context, w http.ResponseWriter, ch reflect.Value) {
+	stream := newContextEventStream(ctx, w)
+	defer stream.close()
+	stream.mu.Lock()
+	stream.start()
+	stream.mu.Unlock()
+	cases := []reflect.SelectCase{
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())},
+		{Dir: reflect.SelectRecv, Chan: ch},
+	}
+	for {
+		chosen, val, ok := reflect.Select(cases)
+		if chosen == 0 || !ok {
+			return
+		}
+		var err error
+		switch event := val.Interface().(type) {
+		case Event:
+			err = stream.Send(event)
+		case *Event:
+			err = stream.Send(*event)
+		default:
+			err = stream.SendData(event)
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+func encodeEvent(event Event) ([]byte, error) {
+	var data string
+	switch val := event.Data.(type) {
+	case nil:
+	case string:
+		data = val
+	case []byte:
+		data = string(val)
+	default:
+		j, err := json.Marshal(val)
+		if err != nil {
+			return nil, fmt.Errorf("event data encode error, err: %w", err)
+		}
+		data = string(j)
+	}
+	buf := &bytes.Buffer{}
+	if event.ID != "" {
+		fmt.Fprintf(buf, "id: %v\n", event.ID)
+	}
+	if event.Event != "" {
+		fmt.Fprintf(buf, "event: %v\n", event.Event)
+	}
+	if event.Retry > 0 {
+		fmt.Fprintf(buf, "retry: %v\n", event.Retry.Milliseconds())
+	}
+	for _, line := range strings.Split(data, "\n") {
+		fmt.Fprintf(buf, "data: %v\n", line)
+	}
+	buf.WriteString("\n")
+	return buf.Bytes(), nil
+}
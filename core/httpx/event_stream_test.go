@@ -0,0 +1,148 @@
+package httpx
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/codeduckcloud/trinity-go/core/e"
+	"github.com/stretchr/testify/assert"
+)
+
+// serveMethod serve the handler the same as the DIHandler
+func serveMethod(t *testing.T, handler interface{}, r *http.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	r = r.WithContext(context.WithValue(r.Context(), HttpxContext, NewContext(r, 0)))
+	inParams, err := InvokeMethod(reflect.TypeOf(handler), r, nil, w)
+	assert.Nil(t, err)
+	HandleResponse(r.Context(), w, reflect.ValueOf(handler).Call(inParams))
+	return w
+}
+
+func withHeartbeatInterval(t *testing.T, interval time.Duration) {
+	original := _heartbeatInterval
+	SetHeartbeatInterval(interval)
+	t.Cleanup(func() {
+		SetHeartbeatInterval(original)
+	})
+}
+
+func TestEncodeEvent(t *testing.T) {
+	payload, err := encodeEvent(Event{ID: "1", Event: "progress", Data: "line1\nline2", Retry: time.Second})
+	assert.Nil(t, err)
+	assert.Equal(t, "id: 1\nevent: progress\nretry: 1000\ndata: line1\ndata: line2\n\n", string(payload))
+
+	payload, err = encodeEvent(Event{Data: map[string]int{"percent": 50}})
+	assert.Nil(t, err)
+	assert.Equal(t, "data: {\"percent\":50}\n\n", string(payload))
+
+	_, err = encodeEvent(Event{Data: make(chan int)})
+	assert.NotNil(t, err)
+}
+
+func TestEventStream_Param(t *testing.T) {
+	withHeartbeatInterval(t, 0)
+	w := serveMethod(t, func(stream EventStream, args *struct {
+		Count int `query_param:"count"`
+	}) error {
+		for i := 0; i < args.Count; i++ {
+			if err := stream.Send(Event{Event: "progress", Data: i}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, httptest.NewRequest(http.MethodGet, "/?count=2", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, MimeEventStream, w.Header().Get(ContentTypeHeader))
+	assert.Equal(t, "no-cache", w.Header().Get(CacheControlHeader))
+	assert.True(t, w.Flushed)
+	assert.Equal(t, "event: progress\ndata: 0\n\nevent: progress\ndata: 1\n\n", w.Body.String())
+}
+
+func TestEventStream_ErrorBeforeStarted(t *testing.T) {
+	w := serveMethod(t, func(stream EventStream) error {
+		return e.ErrInvalidRequest.New("job not found")
+	}, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get(ContentTypeHeader))
+}
+
+func TestEventStream_ErrorAfterStarted(t *testing.T) {
+	withHeartbeatInterval(t, 0)
+	w := serveMethod(t, func(stream EventStream) error {
+		stream.SendData("start")
+		return errors.New("boom")
+	}, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	body := w.Body.String()
+	assert.True(t, strings.HasPrefix(body, "data: start\n\nevent: error\ndata: {"))
+	assert.Contains(t, body, `"code":100001`)
+}
+
+func TestEventStream_Heartbeat(t *testing.T) {
+	withHeartbeatInterval(t, 5*time.Millisecond)
+	w := serveMethod(t, func(stream EventStream) {
+		stream.SendData("start")
+		time.Sleep(30 * time.Millisecond)
+	}, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Contains(t, w.Body.String(), ": ping\n\n")
+}
+
+func TestEventStream_ClientDisconnected(t *testing.T) {
+	withHeartbeatInterval(t, 0)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	var sendErr error
+	w := serveMethod(t, func(stream EventStream) {
+		sendErr = stream.SendData("start")
+	}, httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx))
+	assert.ErrorIs(t, sendErr, context.Canceled)
+	assert.NotEqual(t, MimeEventStream, w.Header().Get(ContentTypeHeader))
+}
+
+func TestStreamChannel(t *testing.T) {
+	withHeartbeatInterval(t, 0)
+	w := serveMethod(t, func() (<-chan interface{}, error) {
+		ch := make(chan interface{}, 3)
+		ch <- "token"
+		ch <- Event{ID: "2", Data: "next"}
+		ch <- &Event{Event: "done"}
+		close(ch)
+		return ch, nil
+	}, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, MimeEventStream, w.Header().Get(ContentTypeHeader))
+	assert.Equal(t, "data: token\n\nid: 2\ndata: next\n\nevent: done\ndata: \n\n", w.Body.String())
+}
+
+func TestStreamChannel_ClientDisconnected(t *testing.T) {
+	withHeartbeatInterval(t, 0)
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := make(chan string)
+	done := make(chan struct{})
+	go func() {
+		serveMethod(t, func() <-chan string {
+			return ch
+		}, httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx))
+		close(done)
+	}()
+	ch <- "token"
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("stream not stopped after the client disconnected")
+	}
+}
+
+func TestStreamChannel_Nil(t *testing.T) {
+	w := serveMethod(t, func() <-chan string {
+		return nil
+	}, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusNoContent, w.Code)
+}
@@ -18,6 +18,13 @@ type HeaderParser interface {
 	Get(h http.Header, k string) string
 }
 
+// MultiValueHeaderParser the header parser support the repeated header values
+// if the parser not implement it, the slice param will only get the value from Get
+type MultiValueHeaderParser interface {
+	HeaderParser
+	Values(h http.Header, k string) []string
+}
+
 type DefaultHeaderParser struct {
 }
 
@@ -29,3 +36,7 @@ func (p *DefaultHeaderParser) Exist(h http.Header, k string) bool {
 func (p *DefaultHeaderParser) Get(h http.Header, k string) string {
 	return h.Get(k)
 }
+
+func (p *DefaultHeaderParser) Values(h http.Header, k string) []string {
+	return h.Values(k)
+}
@@ -1,6 +1,35 @@
 package httpx
 
-import "math"
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/codeduckcloud/trinity-go/core/e"
+)
+
+const (
+	DefaultPageSize    = 20
+	DefaultMaxPageSize = 100
+	OrderAsc           = "asc"
+	OrderDesc          = "desc"
+	LinkHeader         = "Link"
+)
+
+var (
+	_maxPageSize = DefaultMaxPageSize
+)
+
+// SetMaxPageSize set the max page size of PageRequest and CursorRequest
+// the larger page size requested will be limited to the max
+// default: DefaultMaxPageSize
+func SetMaxPageSize(size int) {
+	_maxPageSize = size
+}
 
 type PaginationDTO struct {
 	Total     int64 `json:"total" example:"120"`
@@ -10,10 +39,204 @@ type PaginationDTO struct {
 }
 
 func NewPaginationDTO(pageSize, pageNum int, total int64) *PaginationDTO {
+	totalPage := 0
+	if pageSize > 0 {
+		totalPage = int(math.Ceil(float64(total) / float64(pageSize)))
+	}
 	return &PaginationDTO{
 		Total:     total,
 		PageSize:  pageSize,
-		TotalPage: int(math.Ceil(float64(total) / float64(pageSize))),
+		TotalPage: totalPage,
 		Current:   pageNum,
 	}
 }
+
+// PageRequest the page based pagination request, embed it into the request struct
+//
+//	type ListUserRequest struct {
+//		httpx.PageRequest
+//		Name string `query_param:"name"`
+//	}
+type PageRequest struct {
+	Page     int    `query_param:"page" default:"1" validate:"min=1"`
+	PageSize int    `query_param:"page_size" default:"20" validate:"min=1"`
+	Sort     string `query_param:"sort"`
+	Order    string `query_param:"order" default:"asc" validate:"oneof=asc desc"`
+}
+
+// Size the page size limited by the max page size
+func (p PageRequest) Size() int {
+	return limitPageSize(p.PageSize)
+}
+
+// Offset the offset of the first item in the page
+// the offset is limited to math.MaxInt if the page is too large, so it never overflows to negative
+func (p PageRequest) Offset() int {
+	if p.Page <= 1 {
+		return 0
+	}
+	size := p.Size()
+	if p.Page-1 > math.MaxInt/size {
+		return math.MaxInt
+	}
+	return (p.Page - 1) * size
+}
+
+// IsDesc the order is validated as OrderAsc or OrderDesc, case-sensitive
+func (p PageRequest) IsDesc() bool {
+	return p.Order == OrderDesc
+}
+
+// CursorRequest the cursor based pagination request, embed it into the request struct
+// the cursor is the opaque string encoded by EncodeCursor
+type CursorRequest struct {
+	Cursor string `query_param:"cursor"`
+	Limit  int    `query_param:"limit" default:"20" validate:"min=1"`
+}
+
+// Size the limit limited by the max page size
+func (c CursorRequest) Size() int {
+	return limitPageSize(c.Limit)
+}
+
+// Decode decode the cursor into v, return false if the cursor is empty, which means the first page
+func (c CursorRequest) Decode(v interface{}) (bool, error) {
+	if c.Cursor == "" {
+		return false, nil
+	}
+	return true, DecodeCursor(c.Cursor, v)
+}
+
+type CursorDTO struct {
+	NextCursor string `json:"next_cursor,omitempty" example:"eyJpZCI6MTAwfQ"`
+	PrevCursor string `json:"prev_cursor,omitempty" example:"eyJpZCI6ODF9"`
+	HasMore    bool   `json:"has_more" example:"true"`
+	Limit      int    `json:"limit" example:"20"`
+}
+
+// EncodeCursor encode the v, e.g. the last id of the page, as the opaque cursor
+func EncodeCursor(v interface{}) (string, error) {
+	j, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("cursor encode error, err: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(j), nil
+}
+
+// DecodeCursor decode the cursor encoded by EncodeCursor
+// the invalid cursor is e.ErrInvalidRequest
+func DecodeCursor(cursor string, v interface{}) error {
+	j, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return e.ErrInvalidRequest.Wrap(err, "invalid cursor")
+	}
+	if err := json.Unmarshal(j, v); err != nil {
+		return e.ErrInvalidRequest.Wrap(err, "invalid cursor")
+	}
+	return nil
+}
+
+// Page the paginated result, rendered in the envelope as
+//
+//	{"items": [...], "pagination": {...}}
+//
+// the cursor page render the cursor instead of the pagination
+type Page[T any] struct {
+	Items      []T            `json:"items"`
+	Pagination *PaginationDTO `json:"pagination,omitempty"`
+	Cursor     *CursorDTO     `json:"cursor,omitempty"`
+	links      []string
+}
+
+// NewPage new the page based result
+func NewPage[T any](items []T, req PageRequest, total int64) *Page[T] {
+	if items == nil {
+		items = []T{}
+	}
+	return &Page[T]{
+		Items:      items,
+		Pagination: NewPaginationDTO(req.Size(), req.Page, total),
+	}
+}
+
+// NewCursorPage new the cursor based result, the next cursor is empty if there is no more item
+func NewCursorPage[T any](items []T, req CursorRequest, next, prev string) *Page[T] {
+	if items == nil {
+		items = []T{}
+	}
+	return &Page[T]{
+		Items: items,
+		Cursor: &CursorDTO{
+			NextCursor: next,
+			PrevCursor: prev,
+			HasMore:    next != "",
+			Limit:      req.Size(),
+		},
+	}
+}
+
+// WithLinks emit the RFC 5988 Link header with the first, prev, next and last page of the request url
+// the request could be got by GetRawRequest in the handler
+func (p *Page[T]) WithLinks(r *http.Request) *Page[T] {
+	p.links = p.links[:0]
+	if p.Pagination != nil {
+		pagination := p.Pagination
+		lastPage := pagination.TotalPage
+		if lastPage < 1 {
+			lastPage = 1
+		}
+		p.addPageLink(r, "first", 1)
+		if pagination.Current > 1 {
+			p.addPageLink(r, "prev", pagination.Current-1)
+		}
+		if pagination.Current < lastPage {
+			p.addPageLink(r, "next", pagination.Current+1)
+		}
+		p.addPageLink(r, "last", lastPage)
+	}
+	if p.Cursor != nil {
+		if p.Cursor.PrevCursor != "" {
+			p.addLink(r, "prev", map[string]string{"cursor": p.Cursor.PrevCursor})
+		}
+		if p.Cursor.NextCursor != "" {
+			p.addLink(r, "next", map[string]string{"cursor": p.Cursor.NextCursor})
+		}
+	}
+	return p
+}
+
+// ResponseHeader implement ResponseHeaderer
+func (p *Page[T]) ResponseHeader() http.Header {
+	header := make(http.Header)
+	if len(p.links) > 0 {
+		header.Set(LinkHeader, strings.Join(p.links, ", "))
+	}
+	return header
+}
+
+func (p *Page[T]) addPageLink(r *http.Request, rel string, page int) {
+	p.addLink(r, rel, map[string]string{
+		"page":      strconv.Itoa(page),
+		"page_size": strconv.Itoa(p.Pagination.PageSize),
+	})
+}
+
+func (p *Page[T]) addLink(r *http.Request, rel string, params map[string]string) {
+	u := *r.URL
+	query := u.Query()
+	for k, v := range params {
+		query.Set(k, v)
+	}
+	u.RawQuery = query.Encode()
+	p.links = append(p.links, fmt.Sprintf(`<%v>; rel="%v"`, u.RequestURI(), rel))
+}
+
+func limitPageSize(size int) int {
+	if size <= 0 {
+		return DefaultPageSize
+	}
+	if _maxPageSize > 0 && size > _maxPageSize {
+		return _maxPageSize
+	}
+	return size
+}
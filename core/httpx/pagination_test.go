@@ -1,8 +1,14 @@
 package httpx
 
 import (
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
+	"github.com/codeduckcloud/trinity-go/core/e"
+
 	"github.com/stretchr/testify/assert"
 )
 
@@ -16,3 +22,115 @@ func TestNewPaginationDTO(t *testing.T) {
 	p2 := NewPaginationDTO(20, 1, 121)
 	assert.Equal(t, 7, p2.TotalPage)
 }
+
+func TestNewPaginationDTO_ZeroPageSize(t *testing.T) {
+	p := NewPaginationDTO(0, 1, 120)
+	assert.Equal(t, 0, p.TotalPage)
+	p = NewPaginationDTO(-1, 1, 120)
+	assert.Equal(t, 0, p.TotalPage)
+}
+
+type listUserRequest struct {
+	PageRequest
+	Name string `query_param:"name"`
+}
+
+func TestPageRequest_Parse(t *testing.T) {
+	req := &listUserRequest{}
+	r := httptest.NewRequest(http.MethodGet, "/users?name=trinity", nil)
+	assert.Nil(t, Parse(r, req))
+	assert.Equal(t, 1, req.Page)
+	assert.Equal(t, DefaultPageSize, req.PageSize)
+	assert.Equal(t, OrderAsc, req.Order)
+	assert.Equal(t, 0, req.Offset())
+	assert.False(t, req.IsDesc())
+
+	req = &listUserRequest{}
+	r = httptest.NewRequest(http.MethodGet, "/users?page=3&page_size=1000&sort=name&order=desc", nil)
+	assert.Nil(t, Parse(r, req))
+	assert.Equal(t, 3, req.Page)
+	assert.Equal(t, DefaultMaxPageSize, req.Size())
+	assert.Equal(t, 200, req.Offset())
+	assert.Equal(t, "name", req.Sort)
+	assert.True(t, req.IsDesc())
+	assert.False(t, PageRequest{Order: "DESC"}.IsDesc())
+
+	req = &listUserRequest{}
+	r = httptest.NewRequest(http.MethodGet, "/users?page=9223372036854775807&page_size=100", nil)
+	assert.Nil(t, Parse(r, req))
+	assert.Equal(t, math.MaxInt, req.Offset())
+	assert.Equal(t, math.MaxInt, PageRequest{Page: math.MaxInt / 10, PageSize: 100}.Offset())
+
+	for _, query := range []string{"page=0", "page_size=0", "order=random", "order=DESC"} {
+		r = httptest.NewRequest(http.MethodGet, "/users?"+query, nil)
+		assert.NotNil(t, Parse(r, &listUserRequest{}), query)
+	}
+}
+
+func TestSetMaxPageSize(t *testing.T) {
+	SetMaxPageSize(50)
+	defer SetMaxPageSize(DefaultMaxPageSize)
+	assert.Equal(t, 50, PageRequest{PageSize: 80}.Size())
+	assert.Equal(t, 30, PageRequest{PageSize: 30}.Size())
+	assert.Equal(t, DefaultPageSize, PageRequest{}.Size())
+}
+
+func TestCursor(t *testing.T) {
+	type cursor struct {
+		ID int `json:"id"`
+	}
+	encoded, err := EncodeCursor(cursor{ID: 100})
+	assert.Nil(t, err)
+
+	req := &struct {
+		CursorRequest
+	}{}
+	r := httptest.NewRequest(http.MethodGet, "/users?cursor="+encoded, nil)
+	assert.Nil(t, Parse(r, req))
+	assert.Equal(t, DefaultPageSize, req.Size())
+	var c cursor
+	ok, err := req.Decode(&c)
+	assert.True(t, ok)
+	assert.Nil(t, err)
+	assert.Equal(t, 100, c.ID)
+
+	ok, err = CursorRequest{}.Decode(&c)
+	assert.False(t, ok)
+	assert.Nil(t, err)
+
+	_, err = CursorRequest{Cursor: "not base64!"}.Decode(&c)
+	assert.True(t, errors.Is(err, e.ErrInvalidRequest))
+	_, err = EncodeCursor(make(chan int))
+	assert.NotNil(t, err)
+}
+
+func TestPage(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/users?name=trinity&page=2&page_size=10", nil)
+	w := serveDIParamHandler(func(req listUserRequest) *Page[string] {
+		return NewPage([]string{"a", "b"}, req.PageRequest, 35).WithLinks(r)
+	}, r)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"status":200,"result":{"items":["a","b"],"pagination":{"total":35,"current":2,"total_page":4,"page_size":10}}}`, w.Body.String())
+	assert.Equal(t, `</users?name=trinity&page=1&page_size=10>; rel="first", `+
+		`</users?name=trinity&page=1&page_size=10>; rel="prev", `+
+		`</users?name=trinity&page=3&page_size=10>; rel="next", `+
+		`</users?name=trinity&page=4&page_size=10>; rel="last"`, w.Header().Get(LinkHeader))
+
+	page := NewPage[string](nil, PageRequest{Page: 1, PageSize: 10}, 0).WithLinks(r)
+	assert.Equal(t, []string{}, page.Items)
+	assert.Equal(t, `</users?name=trinity&page=1&page_size=10>; rel="first", </users?name=trinity&page=1&page_size=10>; rel="last"`, page.ResponseHeader().Get(LinkHeader))
+}
+
+func TestCursorPage(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/users?limit=2", nil)
+	page := NewCursorPage([]int{1, 2}, CursorRequest{Limit: 2}, "next", "").WithLinks(r)
+	assert.True(t, page.Cursor.HasMore)
+	assert.Equal(t, 2, page.Cursor.Limit)
+	assert.Nil(t, page.Pagination)
+	assert.Equal(t, `</users?cursor=next&limit=2>; rel="next"`, page.ResponseHeader().Get(LinkHeader))
+
+	page = NewCursorPage[int](nil, CursorRequest{}, "", "prev")
+	assert.False(t, page.Cursor.HasMore)
+	assert.Equal(t, []int{}, page.Items)
+	assert.Empty(t, page.ResponseHeader().Get(LinkHeader))
+}
@@ -0,0 +1,272 @@
+package httpx
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/codeduckcloud/trinity-go/core/utils"
+)
+
+const (
+	// the default value tag of the header, path and query param
+	// the swagger doc will use the same tag as the default value
+	DefaultTag = "default"
+	// the option in the param tag, e.g. `query_param:"page,required"`
+	RequiredOption = "required"
+	// the serialization style of the slice param, same as the OpenAPI style and explode
+	// e.g. `query_param:"id" style:"form" explode:"false"` => ?id=1,2,3
+	StyleTag            = "style"
+	ExplodeTag          = "explode"
+	StyleForm           = "form"
+	StyleSimple         = "simple"
+	StyleSpaceDelimited = "spaceDelimited"
+	StylePipeDelimited  = "pipeDelimited"
+	// the format of the param, e.g. the layout of time.Time
+	// `query_param:"from" format:"2006-01-02"`
+	FormatTag = "format"
+)
+
+// paramTag the parsed header_param, path_param, query_param tag
+type paramTag struct {
+	name     string
+	required bool
+}
+
+func parseParamTag(tag string) paramTag {
+	items := strings.Split(tag, ",")
+	res := paramTag{
+		name: strings.TrimSpace(items[0]),
+	}
+	for _, option := range items[1:] {
+		switch strings.TrimSpace(option) {
+		case RequiredOption:
+			res.required = true
+		}
+	}
+	return res
+}
+
+// paramStyle the serialization style of the param
+type paramStyle struct {
+	style   string
+	explode bool
+	format  string
+}
+
+// parseParamStyle parse the style, explode and format tag
+// the explode is true by default for the form style, false for the others
+func parseParamStyle(field reflect.StructField, defaultStyle string) paramStyle {
+	res := paramStyle{
+		style:  defaultStyle,
+		format: field.Tag.Get(FormatTag),
+	}
+	if style, isExist := field.Tag.Lookup(StyleTag); isExist && style != "" {
+		res.style = style
+	}
+	res.explode = res.style == StyleForm
+	if explode, isExist := field.Tag.Lookup(ExplodeTag); isExist {
+		res.explode, _ = strconv.ParseBool(explode)
+	}
+	return res
+}
+
+// separator the separator of the values in one param
+// empty if the values are not joined
+func (s paramStyle) separator() string {
+	switch s.style {
+	case StyleSpaceDelimited:
+		return " "
+	case StylePipeDelimited:
+		return "|"
+	case StyleSimple:
+		return ","
+	default:
+		if s.explode {
+			return ""
+		}
+		return ","
+	}
+}
+
+func (s paramStyle) split(values []string) []string {
+	sep := s.separator()
+	if sep == "" {
+		return values
+	}
+	res := make([]string, 0, len(values))
+	for _, v := range values {
+		for _, item := range strings.Split(v, sep) {
+			res = append(res, strings.TrimSpace(item))
+		}
+	}
+	return res
+}
+
+// isSliceParam check if the param should be set by multi values
+// []byte is treated as the single value
+func isSliceParam(t reflect.Type) bool {
+	return t.Kind() == reflect.Slice && t.Elem().Kind() != reflect.Uint8
+}
+
+// queryParamValues get the values of the query param
+// for the slice param, will get all the values of `key` and `key[]`
+func queryParamValues(q url.Values, key string, isSlice bool) ([]string, bool) {
+	if !isSlice {
+		if !_defaultQueryParser.Exist(q, key) {
+			return nil, false
+		}
+		return []string{_defaultQueryParser.Get(q, key)}, true
+	}
+	var (
+		res   []string
+		exist bool
+	)
+	for _, k := range []string{key, key + "[]"} {
+		if !_defaultQueryParser.Exist(q, k) {
+			continue
+		}
+		exist = true
+		if p, ok := _defaultQueryParser.(MultiValueQueryParser); ok {
+			res = append(res, p.Values(q, k)...)
+			continue
+		}
+		res = append(res, _defaultQueryParser.Get(q, k))
+	}
+	return res, exist
+}
+
+// headerParamValues get the values of the header param
+// for the slice param, will get all the values of the repeated headers
+func headerParamValues(h http.Header, key string, isSlice bool) ([]string, bool) {
+	if !_defaultHeaderParser.Exist(h, key) {
+		return nil, false
+	}
+	if p, ok := _defaultHeaderParser.(MultiValueHeaderParser); ok && isSlice {
+		return p.Values(h, key), true
+	}
+	return []string{_defaultHeaderParser.Get(h, key)}, true
+}
+
+// setParam set the param values to val
+// if val is slice, all the values will be split by the style and converted to the element
+// otherwise only the first value will be converted
+func setParam(values []string, val *reflect.Value, style paramStyle) error {
+	if !isSliceParam(val.Type()) {
+		word := ""
+		if len(values) > 0 {
+			word = values[0]
+		}
+		return convertParam(word, style.format, val)
+	}
+	words := style.split(values)
+	res := reflect.MakeSlice(val.Type(), len(words), len(words))
+	for i, word := range words {
+		elem := res.Index(i)
+		if err := convertParam(word, style.format, &elem); err != nil {
+			return fmt.Errorf("index %v converted error, err: %v", i, err)
+		}
+	}
+	val.Set(res)
+	return nil
+}
+
+// setDefaultParam set the default value when the param is absent
+// return true if the default value is set
+// return error if the param is required
+// the default value of the slice param is separated by the style separator, `,` by default
+func setDefaultParam(field reflect.StructField, val *reflect.Value, paramType string, tag paramTag, style paramStyle) (bool, error) {
+	if tag.required {
+		return false, fmt.Errorf("%v param %v is required, key: %v", paramType, field.Name, tag.name)
+	}
+	defaultVal, isExist := field.Tag.Lookup(DefaultTag)
+	if !isExist {
+		return false, nil
+	}
+	values := []string{defaultVal}
+	if isSliceParam(val.Type()) && style.separator() == "" {
+		values = strings.Split(defaultVal, ",")
+	}
+	if err := setParam(values, val, style); err != nil {
+		return false, fmt.Errorf("%v param %v default value converted error, err: %v, val: %v", paramType, field.Name, err, defaultVal)
+	}
+	return true, nil
+}
+
+// paramSetter the type set by itself from the param string
+type paramSetter interface {
+	setParam(word string, format string) error
+}
+
+func convertParam(word string, format string, val *reflect.Value) error {
+	if val.CanAddr() {
+		if setter, ok := val.Addr().Interface().(paramSetter); ok {
+			return setter.setParam(word, format)
+		}
+	}
+	return utils.StringConverterWithFormat(word, format, val)
+}
+
+// Optional the param which could tell the absent value from the zero value
+// e.g.
+//
+//	Args struct {
+//		Page httpx.Optional[int] `query_param:"page"`
+//	}
+type Optional[T any] struct {
+	Value T
+	Valid bool
+}
+
+func Some[T any](v T) Optional[T] {
+	return Optional[T]{
+		Value: v,
+		Valid: true,
+	}
+}
+
+// Get get the value and if the value is set
+func (o Optional[T]) Get() (T, bool) {
+	return o.Value, o.Valid
+}
+
+// OrElse get the value, if the value not set will return the defaultVal
+func (o Optional[T]) OrElse(defaultVal T) T {
+	if !o.Valid {
+		return defaultVal
+	}
+	return o.Value
+}
+
+func (o *Optional[T]) setParam(word string, format string) error {
+	val := reflect.ValueOf(&o.Value).Elem()
+	if err := convertParam(word, format, &val); err != nil {
+		return err
+	}
+	o.Valid = true
+	return nil
+}
+
+func (o Optional[T]) MarshalJSON() ([]byte, error) {
+	if !o.Valid {
+		return []byte("null"), nil
+	}
+	return json.Marshal(o.Value)
+}
+
+func (o *Optional[T]) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		var zero T
+		o.Value, o.Valid = zero, false
+		return nil
+	}
+	if err := json.Unmarshal(data, &o.Value); err != nil {
+		return err
+	}
+	o.Valid = true
+	return nil
+}
@@ -0,0 +1,239 @@
+package httpx
+
+import (
+	"encoding/json"
+	"net"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseParamTag(t *testing.T) {
+	assert.Equal(t, paramTag{name: "page"}, parseParamTag("page"))
+	assert.Equal(t, paramTag{name: "page", required: true}, parseParamTag("page, required"))
+	assert.Equal(t, paramTag{name: ""}, parseParamTag(""))
+}
+
+func TestParseDefaultParam(t *testing.T) {
+	type args struct {
+		ID       int              `path_param:"id" default:"1"`
+		Page     int              `query_param:"page" default:"1"`
+		PageSize int              `query_param:"page_size" default:"20"`
+		Lang     string           `header_param:"X-Lang" default:"en"`
+		Keyword  *string          `query_param:"keyword"`
+		Offset   Optional[int]    `query_param:"offset"`
+		Limit    Optional[int]    `query_param:"limit" default:"10"`
+		Name     Optional[string] `query_param:"name"`
+	}
+	r := newReq(&chi.Context{}, "GET", "http://hello.com/?page_size=0&name=", nil, map[string]string{})
+	dest := &args{}
+	assert.NoError(t, Parse(r, dest))
+	assert.Equal(t, &args{
+		ID:       1,
+		Page:     1,
+		PageSize: 0,
+		Lang:     "en",
+		Keyword:  nil,
+		Offset:   Optional[int]{},
+		Limit:    Some(10),
+		Name:     Some(""),
+	}, dest)
+
+	r = newReq(&chi.Context{
+		URLParams: chi.RouteParams{Keys: []string{"id"}, Values: []string{"3"}},
+	}, "GET", "http://hello.com/3?page=2&keyword=a&offset=0", nil, map[string]string{"X-Lang": "zh"})
+	dest = &args{}
+	assert.NoError(t, Parse(r, dest))
+	keyword := "a"
+	assert.Equal(t, &args{
+		ID:       3,
+		Page:     2,
+		PageSize: 20,
+		Lang:     "zh",
+		Keyword:  &keyword,
+		Offset:   Some(0),
+		Limit:    Some(10),
+	}, dest)
+}
+
+func TestParseRequiredParam(t *testing.T) {
+	type queryArgs struct {
+		Page int `query_param:"page,required"`
+	}
+	type headerArgs struct {
+		Token string `header_param:"X-Token,required"`
+	}
+	type pathArgs struct {
+		ID int `path_param:"id,required"`
+	}
+	tests := []struct {
+		name       string
+		v          interface{}
+		wantErrMsg string
+	}{
+		{name: "query", v: &queryArgs{}, wantErrMsg: "query param Page is required, key: page"},
+		{name: "header", v: &headerArgs{}, wantErrMsg: "header param Token is required, key: X-Token"},
+		{name: "path", v: &pathArgs{}, wantErrMsg: "path param ID is required, key: id"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newReq(&chi.Context{}, "GET", "http://hello.com/", nil, map[string]string{})
+			err := Parse(r, tt.v)
+			assert.Error(t, err)
+			assert.Equal(t, tt.wantErrMsg, err.Error())
+		})
+	}
+
+	r := newReq(&chi.Context{}, "GET", "http://hello.com/?page=3", nil, map[string]string{})
+	dest := &queryArgs{}
+	assert.NoError(t, Parse(r, dest))
+	assert.Equal(t, 3, dest.Page)
+}
+
+func TestParseDefaultParam_Invalid(t *testing.T) {
+	type args struct {
+		Page int `query_param:"page" default:"abc"`
+	}
+	r := newReq(&chi.Context{}, "GET", "http://hello.com/", nil, map[string]string{})
+	err := Parse(r, &args{})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "default value converted error")
+
+	type optionalArgs struct {
+		Page Optional[int] `query_param:"page"`
+	}
+	r = newReq(&chi.Context{}, "GET", "http://hello.com/?page=abc", nil, map[string]string{})
+	assert.Error(t, Parse(r, &optionalArgs{}))
+}
+
+func TestOptional(t *testing.T) {
+	var o Optional[int]
+	v, ok := o.Get()
+	assert.Equal(t, 0, v)
+	assert.False(t, ok)
+	assert.Equal(t, 5, o.OrElse(5))
+
+	o = Some(3)
+	v, ok = o.Get()
+	assert.Equal(t, 3, v)
+	assert.True(t, ok)
+	assert.Equal(t, 3, o.OrElse(5))
+}
+
+func TestOptional_JSON(t *testing.T) {
+	type body struct {
+		A Optional[int] `json:"a"`
+		B Optional[int] `json:"b"`
+		C Optional[int] `json:"c"`
+	}
+	b, err := json.Marshal(body{A: Some(1)})
+	assert.NoError(t, err)
+	assert.Equal(t, `{"a":1,"b":null,"c":null}`, string(b))
+
+	var dest body
+	assert.NoError(t, json.Unmarshal([]byte(`{"a":0,"b":null}`), &dest))
+	assert.Equal(t, body{A: Some(0)}, dest)
+	assert.Error(t, json.Unmarshal([]byte(`{"a":"x"}`), &dest))
+}
+
+func TestParseSliceParam(t *testing.T) {
+	type args struct {
+		IDs      []int64  `query_param:"id"`
+		Tags     []string `query_param:"tag" explode:"false"`
+		Pipes    []int    `query_param:"pipe" style:"pipeDelimited"`
+		Spaces   []string `query_param:"space" style:"spaceDelimited"`
+		Brackets []int    `query_param:"b"`
+		Defaults []int    `query_param:"d" default:"1,2"`
+		Codes    []int    `path_param:"codes"`
+		Accepts  []string `header_param:"X-Accept"`
+		First    string   `header_param:"X-Accept"`
+	}
+	r := newReq(&chi.Context{
+		URLParams: chi.RouteParams{Keys: []string{"codes"}, Values: []string{"4,5"}},
+	}, "GET", "http://hello.com/4,5?id=1&id=2&tag=a,b&pipe=1|2|3&space=x+y&b[]=7&b[]=8", nil, map[string]string{})
+	r.Header.Add("X-Accept", "json, xml")
+	r.Header.Add("X-Accept", "yaml")
+	dest := &args{}
+	assert.NoError(t, Parse(r, dest))
+	assert.Equal(t, &args{
+		IDs:      []int64{1, 2},
+		Tags:     []string{"a", "b"},
+		Pipes:    []int{1, 2, 3},
+		Spaces:   []string{"x", "y"},
+		Brackets: []int{7, 8},
+		Defaults: []int{1, 2},
+		Codes:    []int{4, 5},
+		Accepts:  []string{"json", "xml", "yaml"},
+		First:    "json, xml",
+	}, dest)
+}
+
+func TestParseSliceParam_Error(t *testing.T) {
+	type args struct {
+		IDs []int64 `query_param:"id"`
+	}
+	r := newReq(&chi.Context{}, "GET", "http://hello.com/?id=1&id=abc", nil, map[string]string{})
+	err := Parse(r, &args{})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "index 1 converted error")
+}
+
+func TestParseSliceParam_SingleValueParser(t *testing.T) {
+	original := _defaultQueryParser
+	defer SetQueryParser(original)
+	SetQueryParser(&singleValueQueryParser{})
+
+	type args struct {
+		IDs []int `query_param:"id"`
+	}
+	r := newReq(&chi.Context{}, "GET", "http://hello.com/?id=1&id=2", nil, map[string]string{})
+	dest := &args{}
+	assert.NoError(t, Parse(r, dest))
+	assert.Equal(t, []int{1}, dest.IDs)
+}
+
+type singleValueQueryParser struct{}
+
+func (p *singleValueQueryParser) Exist(h url.Values, k string) bool { _, ok := h[k]; return ok }
+func (p *singleValueQueryParser) Get(h url.Values, k string) string { return h.Get(k) }
+
+func TestParseParamStyle(t *testing.T) {
+	type args struct {
+		A []int `style:"form"`
+		B []int `explode:"false"`
+		C []int `style:"simple" explode:"true"`
+		D []int
+	}
+	typ := reflect.TypeOf(args{})
+	a, _ := typ.FieldByName("A")
+	b, _ := typ.FieldByName("B")
+	c, _ := typ.FieldByName("C")
+	d, _ := typ.FieldByName("D")
+	assert.Equal(t, paramStyle{style: StyleForm, explode: true}, parseParamStyle(a, StyleSimple))
+	assert.Equal(t, paramStyle{style: StyleForm, explode: false}, parseParamStyle(b, StyleForm))
+	assert.Equal(t, paramStyle{style: StyleSimple, explode: true}, parseParamStyle(c, StyleForm))
+	assert.Equal(t, paramStyle{style: StyleSimple, explode: false}, parseParamStyle(d, StyleSimple))
+	assert.Equal(t, []string{"1", "2"}, paramStyle{style: StyleSimple}.split([]string{"1, 2"}))
+	assert.Equal(t, []string{"1,2"}, paramStyle{style: StyleForm, explode: true}.split([]string{"1,2"}))
+}
+
+func TestParseFormatParam(t *testing.T) {
+	type args struct {
+		From    time.Time           `query_param:"from" format:"2006-01-02"`
+		To      Optional[time.Time] `query_param:"to" format:"unix"`
+		Timeout time.Duration       `header_param:"X-Timeout" default:"5s"`
+		IPs     []net.IP            `query_param:"ip"`
+	}
+	r := newReq(&chi.Context{}, "GET", "http://hello.com/?from=2021-10-02&to=1633136720&ip=10.0.0.1&ip=::1", nil, map[string]string{})
+	dest := &args{}
+	assert.NoError(t, Parse(r, dest))
+	assert.Equal(t, time.Date(2021, 10, 2, 0, 0, 0, 0, time.UTC), dest.From)
+	assert.True(t, dest.To.Valid)
+	assert.Equal(t, int64(1633136720), dest.To.Value.Unix())
+	assert.Equal(t, 5*time.Second, dest.Timeout)
+	assert.Equal(t, []net.IP{net.ParseIP("10.0.0.1"), net.ParseIP("::1")}, dest.IPs)
+}
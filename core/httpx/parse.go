@@ -8,15 +8,20 @@ import (
 	"io/ioutil"
 	"net/http"
 	"reflect"
+	"strconv"
+	"strings"
 
-	"github.com/codeduckcloud/trinity-go/core/utils"
+	"github.com/codeduckcloud/trinity-go/core/wsx"
 	"github.com/go-chi/chi/v5"
 )
 
 var (
-	contextType    = reflect.ValueOf(context.Background()).Type()
-	httpWriterType = reflect.ValueOf(NewWriter()).Type()
-	requestType    = reflect.ValueOf(&http.Request{}).Type()
+	contextType     = reflect.ValueOf(context.Background()).Type()
+	httpWriterType  = reflect.ValueOf(NewWriter()).Type()
+	requestType     = reflect.ValueOf(&http.Request{}).Type()
+	errorType       = reflect.TypeOf((*error)(nil)).Elem()
+	eventStreamType = reflect.TypeOf((*EventStream)(nil)).Elem()
+	wsConnType      = reflect.TypeOf(&wsx.Conn{})
 )
 
 type w struct {
@@ -41,8 +46,10 @@ const (
 )
 
 type Context struct {
-	r    *http.Request
-	code int
+	r      *http.Request
+	code   int
+	stream *eventStream
+	ws     *wsx.Conn
 }
 
 func NewContext(r *http.Request, code int) *Context {
@@ -79,6 +86,32 @@ func GetRawRequest(ctx context.Context) *http.Request {
 	return val.r
 }
 
+// Parse
+/*
+ @r the http request
+ @v the pointer of the struct to be set
+
+ Parse will set the struct fields according to the tags
+ header_param, path_param, query_param, claims_param, body_param
+ and validate the struct with the validator after all the fields set
+
+ for the header, path and query param
+ `default:"20"` will be set when the param is absent
+ `query_param:"page,required"` will return error when the param is absent
+ the pointer field and Optional[T] field will be kept as nil / invalid when the param is absent
+
+ the slice field will be set by the repeated values, e.g. ?id=1&id=2 or ?id[]=1&id[]=2
+ `style` and `explode` tag could change the way to split the values, same as the OpenAPI
+ e.g. `query_param:"id" explode:"false"` => ?id=1,2
+ the header and path param are split by `,` by default
+
+ the param could be any type supported by utils.StringConverterWithFormat
+ e.g. time.Time, time.Duration, net.IP, *big.Int and the encoding.TextUnmarshaler
+ `format` tag will be passed to the converter, e.g. `query_param:"from" format:"2006-01-02"`
+
+ the body param could get the nested value by the dotted path or the JSON pointer
+ e.g. `body_param:"user.address.city"`, `body_param:"/items/0/id"`
+*/
 func Parse(r *http.Request, v interface{}) error {
 	if v == nil {
 		return fmt.Errorf("parsing error , empty value to parse")
@@ -91,25 +124,43 @@ func Parse(r *http.Request, v interface{}) error {
 			return fmt.Errorf("di param : %v is not exported , cannot set", inType.Field(index).Name)
 		}
 		if headerParam, isExist := inType.Field(index).Tag.Lookup("header_param"); isExist {
-			if _defaultHeaderParser.Exist(r.Header, headerParam) {
-				headerValString := _defaultHeaderParser.Get(r.Header, headerParam)
-				if err := utils.StringConverter(headerValString, &val); err != nil {
-					return fmt.Errorf("header param %v converted error, cannot set ,err:%v ,  val : %v  ", inType.Field(index).Name, err, headerValString)
+			tag := parseParamTag(headerParam)
+			style := parseParamStyle(inType.Field(index), StyleSimple)
+			if headerVals, exist := headerParamValues(r.Header, tag.name, isSliceParam(val.Type())); exist {
+				if err := setParam(headerVals, &val, style); err != nil {
+					return fmt.Errorf("header param %v converted error, cannot set ,err:%v ,  val : %v  ", inType.Field(index).Name, err, strings.Join(headerVals, ","))
+				}
+				continue
+			}
+			if isSet, err := setDefaultParam(inType.Field(index), &val, "header", tag, style); isSet || err != nil {
+				if err != nil {
+					return err
 				}
 				continue
 			}
 		}
 		// check if path param
 		if pathParam, isExist := inType.Field(index).Tag.Lookup("path_param"); isExist {
-			paramValString := chi.URLParam(r, pathParam)
-			if err := utils.StringConverter(paramValString, &val); err != nil {
+			tag := parseParamTag(pathParam)
+			style := parseParamStyle(inType.Field(index), StyleSimple)
+			paramValString := chi.URLParam(r, tag.name)
+			if paramValString == "" {
+				if isSet, err := setDefaultParam(inType.Field(index), &val, "path", tag, style); isSet || err != nil {
+					if err != nil {
+						return err
+					}
+					continue
+				}
+			}
+			if err := setParam([]string{paramValString}, &val, style); err != nil {
 				return fmt.Errorf("path param %v converted error, cannot set , err:%v  val : %v  ", inType.Field(index).Name, err, paramValString)
 			}
 			continue
 		}
 		// check if query param
 		if queryParam, isExist := inType.Field(index).Tag.Lookup("query_param"); isExist {
-			if queryParam == "" {
+			tag := parseParamTag(queryParam)
+			if tag.name == "" {
 				switch val.Type().Kind() {
 				case reflect.String:
 					val.Set(reflect.ValueOf(r.URL.RawQuery))
@@ -142,15 +193,24 @@ func Parse(r *http.Request, v interface{}) error {
 					return fmt.Errorf("param %v get all query param converted error, only support string , val : %v ", inType.Field(index).Name, r.URL.RawQuery)
 				}
 			} else {
-				if _defaultQueryParser.Exist(r.URL.Query(), queryParam) {
-					queryValString := _defaultQueryParser.Get(r.URL.Query(), queryParam)
-					if err := utils.StringConverter(queryValString, &val); err != nil {
-						return fmt.Errorf("param %v converted error, err :%v , val : %v ", inType.Field(index).Name, err, queryValString)
+				style := parseParamStyle(inType.Field(index), StyleForm)
+				if queryVals, exist := queryParamValues(r.URL.Query(), tag.name, isSliceParam(val.Type())); exist {
+					if err := setParam(queryVals, &val, style); err != nil {
+						return fmt.Errorf("param %v converted error, err :%v , val : %v ", inType.Field(index).Name, err, strings.Join(queryVals, ","))
 					}
+				} else if _, err := setDefaultParam(inType.Field(index), &val, "query", tag, style); err != nil {
+					return err
 				}
 			}
 			continue
 		}
+		// check if claims param
+		if claimsParam, isExist := inType.Field(index).Tag.Lookup(ClaimsTag); isExist {
+			if err := setClaimsParam(r, inType.Field(index), &val, claimsParam); err != nil {
+				return err
+			}
+			continue
+		}
 		// check if body param
 		if bodyParam, isExist := inType.Field(index).Tag.Lookup("body_param"); isExist {
 			respBytes, err := ioutil.ReadAll(r.Body)
@@ -208,7 +268,9 @@ func Parse(r *http.Request, v interface{}) error {
 			} else {
 				bodyVal := make(map[string]interface{})
 				if len(respBytes) > 0 {
-					if err := json.Unmarshal(respBytes, &bodyVal); err != nil {
+					d := json.NewDecoder(bytes.NewReader(respBytes))
+					d.UseNumber()
+					if err := d.Decode(&bodyVal); err != nil {
 						return fmt.Errorf("param %v converted error,err :%v , val : %v ", inType.Field(index).Name, err, string(respBytes))
 					}
 				}
@@ -216,6 +278,10 @@ func Parse(r *http.Request, v interface{}) error {
 				if err != nil {
 					return fmt.Errorf("param %v converted error,err :%v , val : %v ", inType.Field(index).Name, err, bodyVal)
 				}
+				if value == nil {
+					val.Set(reflect.Zero(val.Type()))
+					continue
+				}
 				val.Set(reflect.ValueOf(value))
 			}
 			continue
@@ -235,8 +301,12 @@ func Parse(r *http.Request, v interface{}) error {
 			val.Set(reflect.ValueOf(newDest))
 		}
 	}
-	if err := _defaultValidator.Struct(v); err != nil {
-		return fmt.Errorf("httpx.Parse validate error, err: %v", err)
+	ctx := context.Background()
+	if r != nil {
+		ctx = r.Context()
+	}
+	if err := _defaultValidator.StructCtx(ctx, v); err != nil {
+		return fmt.Errorf("httpx.Parse validate error, err: %w", err)
 	}
 	return nil
 }
@@ -244,51 +314,66 @@ func Parse(r *http.Request, v interface{}) error {
 // bodyParamConverter
 /*
  @bodyVal the father val of
- @key the key name of the parentVal
+ @key the path of the value in the bodyVal
  @destType the type of dest type
- bodyParamConverter will get the key from the body value
+ bodyParamConverter will get the value from the body value by the path
  and convert the value to the dest type value
+
+ the path could be the top level key, the dotted path `user.address.city`
+ or the JSON pointer `/user/address/city`, the array index is supported, e.g. `items.0.id`
+ if the value not exist, the pointer type will return nil, the others will return error
 */
 func bodyParamConverter(bodyVal map[string]interface{}, key string, destType reflect.Type) (interface{}, error) {
-	value, ok := bodyVal[key]
+	value, ok := lookupBodyParam(bodyVal, key)
 	if !ok {
+		if destType.Kind() == reflect.Ptr {
+			return reflect.Zero(destType).Interface(), nil
+		}
 		return nil, fmt.Errorf("key %v not exist", key)
 	}
-	switch destType.Kind() {
-	case reflect.Int64:
-		convertedValue, ok := value.(int64)
-		if !ok {
-			return nil, fmt.Errorf("key %v convert to int64 error ", key)
-		}
-		return convertedValue, nil
-	case reflect.Int32:
-		convertedValue, ok := value.(int32)
-		if !ok {
-			return nil, fmt.Errorf("key %v convert to int32 error ", key)
-		}
-		return convertedValue, nil
-	case reflect.Int:
-		convertedValue, ok := value.(int)
-		if !ok {
-			return nil, fmt.Errorf("key %v convert to int error ", key)
+	c, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("key %v convert to %v error, err: %v", key, destType, err)
+	}
+	targetVal := reflect.New(destType)
+	if err := json.Unmarshal(c, targetVal.Interface()); err != nil {
+		return nil, fmt.Errorf("key %v convert to %v error, err: %v", key, destType, err)
+	}
+	return targetVal.Elem().Interface(), nil
+}
+
+// lookupBodyParam get the value from the body value by the path
+func lookupBodyParam(bodyVal map[string]interface{}, path string) (interface{}, bool) {
+	var segments []string
+	if strings.HasPrefix(path, "/") {
+		segments = strings.Split(path[1:], "/")
+		for i := range segments {
+			segments[i] = strings.ReplaceAll(strings.ReplaceAll(segments[i], "~1", "/"), "~0", "~")
 		}
-		return convertedValue, nil
-	case reflect.String:
-		convertedValue, ok := value.(string)
-		if !ok {
-			return nil, fmt.Errorf("key %v convert to string error ", key)
+	} else {
+		if value, ok := bodyVal[path]; ok {
+			return value, true
 		}
-		return convertedValue, nil
-	case reflect.Struct:
-		c, _ := json.Marshal(value)
-		targetVal := reflect.New(destType).Interface()
-		decoder := json.NewDecoder(bytes.NewReader(c))
-		if err := decoder.Decode(targetVal); err != nil {
-			return nil, err
+		segments = strings.Split(path, ".")
+	}
+	var current interface{} = bodyVal
+	for _, segment := range segments {
+		switch v := current.(type) {
+		case map[string]interface{}:
+			value, ok := v[segment]
+			if !ok {
+				return nil, false
+			}
+			current = value
+		case []interface{}:
+			index, err := strconv.Atoi(segment)
+			if err != nil || index < 0 || index >= len(v) {
+				return nil, false
+			}
+			current = v[index]
+		default:
+			return nil, false
 		}
-		return reflect.Indirect(reflect.ValueOf(targetVal)).Interface(), nil
-	default:
-		return nil, fmt.Errorf("type %v not support", destType)
 	}
-
+	return current, true
 }
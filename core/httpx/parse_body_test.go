@@ -134,9 +134,62 @@ func TestParse_BodyParam_Named(t *testing.T) {
 	body := `{"a":1,"b":2,"c":3,"d":"hello","e":{"v":9}}`
 	r := makeJSONReq(t, body)
 	var p payload
-	// b/c the nested 'b' is float64 after json decode, but converter expects int64 ;
-	// use bodyParamConverter directly to reach all paths
-	_ = Parse(r, &p)
+	assert.NoError(t, Parse(r, &p))
+	assert.Equal(t, payload{A: 1, B: 2, C: 3, D: "hello", E: inner{V: 9}}, p)
+}
+
+func TestParse_BodyParam_Path(t *testing.T) {
+	type address struct {
+		City string `json:"city"`
+	}
+	type payload struct {
+		City     string            `body_param:"user.address.city"`
+		Address  address           `body_param:"/user/address"`
+		Slash    string            `body_param:"/a~1b"`
+		Dotted   bool              `body_param:"x.y"`
+		FirstID  int64             `body_param:"items.0.id"`
+		IDs      []int64           `body_param:"ids"`
+		Ratio    float32           `body_param:"ratio"`
+		Tags     map[string]string `body_param:"tags"`
+		Nickname *string           `body_param:"user.nickname"`
+		Age      *int              `body_param:"user.age"`
+		Raw      interface{}       `body_param:"raw"`
+		Big      int64             `body_param:"big"`
+	}
+	body := `{"user":{"address":{"city":"SG"},"age":18},"a/b":"slash","x.y":true,"items":[{"id":7}],"ids":[1,2],"ratio":0.5,"tags":{"k":"v"},"raw":null,"big":9007199254740993}`
+	r := makeJSONReq(t, body)
+	var p payload
+	assert.NoError(t, Parse(r, &p))
+	age := 18
+	assert.Equal(t, payload{
+		City:     "SG",
+		Address:  address{City: "SG"},
+		Slash:    "slash",
+		Dotted:   true,
+		FirstID:  7,
+		IDs:      []int64{1, 2},
+		Ratio:    0.5,
+		Tags:     map[string]string{"k": "v"},
+		Nickname: nil,
+		Age:      &age,
+		Raw:      nil,
+		Big:      9007199254740993,
+	}, p)
+}
+
+func TestBodyParamConverter_PathErrors(t *testing.T) {
+	body := map[string]interface{}{
+		"user":  map[string]interface{}{"name": "daniel"},
+		"items": []interface{}{map[string]interface{}{"id": 1}},
+	}
+	for _, key := range []string{"user.age", "user.name.first", "items.1.id", "items.x.id", "/user/age"} {
+		_, err := bodyParamConverter(body, key, reflect.TypeOf(int(0)))
+		assert.Error(t, err, key)
+		assert.Equal(t, "key "+key+" not exist", err.Error())
+	}
+	_, err := bodyParamConverter(body, "user.name", reflect.TypeOf(int(0)))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "key user.name convert to int error")
 }
 
 func TestParse_BodyParam_InvalidJson(t *testing.T) {
@@ -311,7 +364,7 @@ func TestParse_HeaderParamError(t *testing.T) {
 		V []int `header_param:"X-Test"`
 	}
 	r, _ := http.NewRequest(http.MethodGet, "http://example.com/", nil)
-	r.Header.Set("X-Test", "1")
+	r.Header.Set("X-Test", "abc")
 	var p payload
 	err := Parse(r, &p)
 	assert.Error(t, err)
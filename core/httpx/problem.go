@@ -0,0 +1,73 @@
+package httpx
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const (
+	MimeProblemJSON = "application/problem+json"
+	// the default type base uri of the problem, the type will be `urn:error:400001`
+	DefaultProblemTypeBaseURI = "urn:error:"
+)
+
+// ProblemDetails the RFC 7807 problem details
+// code, error_id and trace_id are the extension members
+type ProblemDetails struct {
+	Type     string `json:"type" example:"urn:error:400001"`
+	Title    string `json:"title" example:"invalid request"`
+	Status   int    `json:"status" example:"400"`
+	Detail   string `json:"detail,omitempty" example:"error detail1; error detail2"`
+	Instance string `json:"instance,omitempty" example:"/users/1"`
+	Code     int    `json:"code" example:"400001"`
+	ErrorID  string `json:"error_id,omitempty" example:"1577170945871564800"`
+	TraceID  string `json:"trace_id,omitempty" example:"1-trace-it"`
+}
+
+// ProblemEnvelope write the error as application/problem+json
+// the error code => type, message => title, details => detail
+type ProblemEnvelope struct {
+	// the base uri of the problem type, the error code will be appended
+	// default: DefaultProblemTypeBaseURI
+	TypeBaseURI string
+	// the envelope to write the result, the problem details is only for the error
+	// default: ResponseEnvelope
+	ResultEnvelope Envelope
+}
+
+func (p ProblemEnvelope) WriteResult(ctx context.Context, w http.ResponseWriter, status int, res interface{}) {
+	if p.ResultEnvelope != nil {
+		p.ResultEnvelope.WriteResult(ctx, w, status, res)
+		return
+	}
+	ResponseEnvelope{}.WriteResult(ctx, w, status, res)
+}
+
+func (p ProblemEnvelope) WriteError(ctx context.Context, w http.ResponseWriter, status int, err *ErrorInfo) {
+	baseURI := p.TypeBaseURI
+	if baseURI == "" {
+		baseURI = DefaultProblemTypeBaseURI
+	}
+	problem := &ProblemDetails{
+		Type:    fmt.Sprintf("%v%v", baseURI, err.Code),
+		Title:   err.Message,
+		Status:  status,
+		Detail:  strings.Join(err.Details, "; "),
+		Code:    err.Code,
+		ErrorID: err.ErrorID,
+		TraceID: getTraceID(ctx),
+	}
+	if r := rawRequest(ctx); r != nil {
+		problem.Instance = r.URL.Path
+	}
+	j, marshalErr := json.Marshal(problem)
+	if marshalErr != nil {
+		panic(marshalErr)
+	}
+	w.Header().Set(ContentTypeHeader, MimeProblemJSON)
+	w.WriteHeader(status)
+	w.Write(j)
+}
@@ -17,6 +17,14 @@ type QueryParser interface {
 	Get(h url.Values, k string) string
 }
 
+// MultiValueQueryParser the query parser support the repeated values
+// e.g. ?id=1&id=2
+// if the parser not implement it, the slice param will only get the value from Get
+type MultiValueQueryParser interface {
+	QueryParser
+	Values(h url.Values, k string) []string
+}
+
 type DefaultQueryParser struct {
 }
 
@@ -28,3 +36,7 @@ func (p *DefaultQueryParser) Exist(h url.Values, k string) bool {
 func (p *DefaultQueryParser) Get(h url.Values, k string) string {
 	return h.Get(k)
 }
+
+func (p *DefaultQueryParser) Values(h url.Values, k string) []string {
+	return h[k]
+}
@@ -13,8 +13,8 @@ import (
 	"net/http"
 
 	"github.com/codeduckcloud/trinity-go/core/e"
-	"github.com/opentracing/opentracing-go"
-	"github.com/uber/jaeger-client-go"
+	"github.com/codeduckcloud/trinity-go/core/requestid"
+	"github.com/codeduckcloud/trinity-go/core/tracex"
 )
 
 const (
@@ -53,32 +53,51 @@ type ErrorInfo struct {
 	Code    int      `json:"code" example:"400001"`
 	Message string   `json:"message" example:"ErrInvalidRequest"`
 	Details []string `json:"details" example:"error detail1,error detail2"`
+	// the id to find the full error in the log
+	ErrorID string `json:"error_id,omitempty" example:"1577170945871564800"`
 }
 
+// HttpResponseErr response the error with the http status
+// the status is chosen by the order
+// 1. the error status set by SetHttpStatusCode
+// 2. the status of the error, see e.HTTPStatus
+// 3. DefaultHttpErrorCode
+// the full error will be logged with the error id, see SetErrorMode for the detail responded
+// the error is written by the envelope, see SetEnvelope and UseEnvelope
 func HttpResponseErr(ctx context.Context, w http.ResponseWriter, err error) {
+	status, info := resolveError(ctx, err)
+	GetEnvelope(ctx).WriteError(ctx, w, status, info)
+}
+
+// resolveError resolve the status and the error info responded, the full error is logged
+func resolveError(ctx context.Context, err error) (int, *ErrorInfo) {
 	wrapErr := e.FromErr(err)
-	res := &Response{
-		Status: GetHTTPStatusCode(ctx, DefaultHttpErrorCode),
-		Error: &ErrorInfo{
-			Code:    wrapErr.Code(),
-			Message: wrapErr.Message(),
-			Details: wrapErr.Details(),
-		},
+	status := GetHTTPErrorStatusCode(ctx, wrapErr)
+	errorID := newErrorID()
+	logError(ctx, wrapErr, status, errorID)
+	return status, newErrorInfo(wrapErr, status, errorID)
+}
+
+// GetHTTPErrorStatusCode get the http status of the error response
+func GetHTTPErrorStatusCode(ctx context.Context, err error) int {
+	if status := GetHTTPStatusCode(ctx, 0); status >= 400 {
+		return status
 	}
-	JsonResponse(w, DefaultHttpErrorCode, res)
+	if status := e.HTTPStatus(err); status != 0 {
+		return status
+	}
+	return DefaultHttpErrorCode
 }
 
+// HttpResponse response the result with the envelope, see SetEnvelope and UseEnvelope
 func HttpResponse(ctx context.Context, w http.ResponseWriter, status int, res interface{}) {
-	result := &Response{
-		Status: status,
-		Result: res,
-	}
-	x := opentracing.SpanFromContext(ctx)
-	if x != nil {
-		sc, ok := x.Context().(jaeger.SpanContext)
-		if ok {
-			result.TraceID = sc.TraceID().String()
-		}
+	GetEnvelope(ctx).WriteResult(ctx, w, status, res)
+}
+
+// getTraceID the trace id of the OpenTelemetry span, or the request id if no span in the ctx
+func getTraceID(ctx context.Context) string {
+	if traceID := tracex.TraceID(ctx); traceID != "" {
+		return traceID
 	}
-	JsonResponse(w, status, result)
+	return requestid.FromCtx(ctx)
 }
@@ -5,14 +5,14 @@ import (
 	"encoding/json"
 	"encoding/xml"
 	"errors"
+	"net/http"
 	"net/http/httptest"
 	"testing"
 
 	"github.com/codeduckcloud/trinity-go/core/e"
-	"github.com/opentracing/opentracing-go"
-	"github.com/opentracing/opentracing-go/mocktracer"
+	"github.com/codeduckcloud/trinity-go/core/requestid"
+	"github.com/codeduckcloud/trinity-go/core/tracex"
 	"github.com/stretchr/testify/assert"
-	"github.com/uber/jaeger-client-go"
 )
 
 func TestJsonResponse(t *testing.T) {
@@ -57,7 +57,7 @@ func TestXMLResponse_Panics(t *testing.T) {
 func TestHttpResponseErr(t *testing.T) {
 	rr := httptest.NewRecorder()
 	HttpResponseErr(context.Background(), rr, errors.New("boom"))
-	assert.Equal(t, DefaultHttpErrorCode, rr.Code)
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
 
 	var resp Response
 	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
@@ -70,7 +70,7 @@ func TestHttpResponseErr_WithCustomStatus(t *testing.T) {
 	rr := httptest.NewRecorder()
 	ctx := context.WithValue(context.Background(), HttpxContext, &Context{code: 418})
 	HttpResponseErr(ctx, rr, e.New(4001, "bad", "x"))
-	assert.Equal(t, DefaultHttpErrorCode, rr.Code)
+	assert.Equal(t, 418, rr.Code)
 
 	var resp Response
 	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
@@ -78,6 +78,34 @@ func TestHttpResponseErr_WithCustomStatus(t *testing.T) {
 	assert.Equal(t, 4001, resp.Error.Code)
 }
 
+func TestHttpResponseErr_StatusMapping(t *testing.T) {
+	tests := []struct {
+		name string
+		ctx  context.Context
+		err  error
+		want int
+	}{
+		{name: "unknown", ctx: context.Background(), err: errors.New("boom"), want: http.StatusInternalServerError},
+		{name: "catalog code", ctx: context.Background(), err: e.New(e.ForbiddenError, "forbidden"), want: 403},
+		{name: "undefined code", ctx: context.Background(), err: e.New(404001, "not found"), want: http.StatusInternalServerError},
+		{name: "explicit status", ctx: context.Background(), err: e.NewWithStatus(409, 1001, "conflict"), want: 409},
+		{name: "panic", ctx: context.Background(), err: e.New(e.PanicError, "panic"), want: 500},
+		{name: "success status in ctx", ctx: context.WithValue(context.Background(), HttpxContext, &Context{code: 200}), err: e.New(401001, "unauthorized"), want: 401},
+		{name: "error status in ctx", ctx: context.WithValue(context.Background(), HttpxContext, &Context{code: 503}), err: e.New(401001, "unauthorized"), want: 503},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			HttpResponseErr(tt.ctx, rr, tt.err)
+			assert.Equal(t, tt.want, rr.Code)
+
+			var resp Response
+			assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			assert.Equal(t, tt.want, resp.Status)
+		})
+	}
+}
+
 func TestHttpResponse_NoTrace(t *testing.T) {
 	rr := httptest.NewRecorder()
 	HttpResponse(context.Background(), rr, 200, map[string]string{"hello": "world"})
@@ -89,36 +117,26 @@ func TestHttpResponse_NoTrace(t *testing.T) {
 	assert.Empty(t, resp.TraceID)
 }
 
-func TestHttpResponse_WithMockTracer(t *testing.T) {
-	// mocktracer span context is NOT a jaeger.SpanContext so TraceID will be empty.
-	tracer := mocktracer.New()
-	span := tracer.StartSpan("test")
-	defer span.Finish()
-	ctx := opentracing.ContextWithSpan(context.Background(), span)
+func TestHttpResponse_WithSpan(t *testing.T) {
+	tp, _ := tracex.NewInMemoryProvider()
+	ctx, span := tp.Tracer("test").Start(requestid.WithCtx(context.Background(), "req-1"), "op")
+	defer span.End()
 
 	rr := httptest.NewRecorder()
-	HttpResponse(ctx, rr, 200, nil)
+	HttpResponse(ctx, rr, 200, map[string]string{"ok": "yes"})
 
 	var resp Response
 	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
-	assert.Empty(t, resp.TraceID)
+	assert.Equal(t, span.SpanContext().TraceID().String(), resp.TraceID)
 }
 
-func TestHttpResponse_WithJaegerSpan(t *testing.T) {
-	tracer, closer := jaeger.NewTracer(
-		"test",
-		jaeger.NewConstSampler(true),
-		jaeger.NewNullReporter(),
-	)
-	defer closer.Close()
-	span := tracer.StartSpan("op")
-	defer span.Finish()
-	ctx := opentracing.ContextWithSpan(context.Background(), span)
+func TestHttpResponse_RequestIDAsTraceID(t *testing.T) {
+	ctx := requestid.WithCtx(context.Background(), "req-1")
 
 	rr := httptest.NewRecorder()
-	HttpResponse(ctx, rr, 200, map[string]string{"ok": "yes"})
+	HttpResponse(ctx, rr, 200, nil)
 
 	var resp Response
 	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
-	assert.NotEmpty(t, resp.TraceID)
+	assert.Equal(t, "req-1", resp.TraceID)
 }
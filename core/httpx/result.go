@@ -0,0 +1,198 @@
+package httpx
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"time"
+)
+
+const (
+	LocationHeader          = "Location"
+	InlineDispositionFormat = "inline; filename=%s"
+)
+
+// Result the handler result with the status, headers and cookies
+// the body is written by
+// 1. nil => only the status, default 204
+// 2. *File / io.Reader => stream the content, see File
+// 3. others => the envelope, see SetEnvelope
+type Result struct {
+	// default: 200, 204 if the body is nil
+	Status  int
+	Header  http.Header
+	Cookies []*http.Cookie
+	Body    interface{}
+}
+
+// NewResult new the result with the status and the body
+func NewResult(status int, body interface{}) *Result {
+	return &Result{
+		Status: status,
+		Body:   body,
+	}
+}
+
+// WithHeader add the header to the result
+func (r *Result) WithHeader(key, value string) *Result {
+	if r.Header == nil {
+		r.Header = make(http.Header)
+	}
+	r.Header.Add(key, value)
+	return r
+}
+
+// WithCookie add the cookie to the result
+func (r *Result) WithCookie(cookie *http.Cookie) *Result {
+	r.Cookies = append(r.Cookies, cookie)
+	return r
+}
+
+// Redirect redirect to the url
+// default status: 302
+func Redirect(url string, status ...int) *Result {
+	code := http.StatusFound
+	if len(status) > 0 {
+		code = status[0]
+	}
+	return NewResult(code, nil).WithHeader(LocationHeader, url)
+}
+
+// ResponseHeaderer the result body which set the response headers, e.g. Page with the Link header
+type ResponseHeaderer interface {
+	ResponseHeader() http.Header
+}
+
+// File the file result
+// if the content is io.ReadSeeker, it is served by http.ServeContent which supports the range request
+// if the content is io.Closer, it will be closed after responded
+type File struct {
+	// the filename in Content-Disposition, if empty and the content is *os.File, the base name of the file is used
+	Name    string
+	Content io.Reader
+	// default: detected by the file extension
+	ContentType string
+	// the Last-Modified header, only for the io.ReadSeeker
+	ModTime time.Time
+	// respond Content-Disposition as inline instead of attachment
+	Inline bool
+}
+
+func (r *Result) write(ctx context.Context, w http.ResponseWriter) {
+	for key, values := range r.Header {
+		for _, value := range values {
+			w.Header().Add(key, value)
+		}
+	}
+	for _, cookie := range r.Cookies {
+		http.SetCookie(w, cookie)
+	}
+	if headerer, ok := r.Body.(ResponseHeaderer); ok && !isNilResult(r.Body) {
+		for key, values := range headerer.ResponseHeader() {
+			for _, value := range values {
+				w.Header().Add(key, value)
+			}
+		}
+	}
+	if isNilResult(r.Body) {
+		status := r.Status
+		if status == 0 {
+			status = GetHTTPStatusCode(ctx, http.StatusNoContent)
+		}
+		w.WriteHeader(status)
+		return
+	}
+	status := r.Status
+	if status == 0 {
+		status = GetHTTPStatusCode(ctx, DefaultHttpSuccessCode)
+	}
+	switch body := r.Body.(type) {
+	case *File:
+		body.write(ctx, w, status)
+	case File:
+		body.write(ctx, w, status)
+	case io.Reader:
+		(&File{Content: body}).write(ctx, w, status)
+	default:
+		HttpResponse(ctx, w, status, body)
+	}
+}
+
+func (f *File) write(ctx context.Context, w http.ResponseWriter, status int) {
+	if closer, ok := f.Content.(io.Closer); ok {
+		defer closer.Close()
+	}
+	name := f.Name
+	if file, ok := f.Content.(*os.File); ok && name == "" {
+		name = filepath.Base(file.Name())
+	}
+	if name != "" {
+		format := DispositionFormat
+		if f.Inline {
+			format = InlineDispositionFormat
+		}
+		w.Header().Set(ContentDispositionHeader, fmt.Sprintf(format, name))
+	}
+	contentType := f.ContentType
+	if contentType == "" {
+		contentType = mime.TypeByExtension(filepath.Ext(name))
+	}
+	if contentType != "" {
+		w.Header().Set(ContentTypeHeader, contentType)
+	}
+	seeker, ok := f.Content.(io.ReadSeeker)
+	r := rawRequest(ctx)
+	if ok && r != nil && status == http.StatusOK {
+		http.ServeContent(w, r, name, f.ModTime, seeker)
+		return
+	}
+	if contentType == "" {
+		w.Header().Set(ContentTypeHeader, MimeOctetStream)
+	}
+	w.WriteHeader(status)
+	io.Copy(w, f.Content)
+}
+
+// writeResult write the result returned by the handler
+// nil pointer => 204, <-chan T => server-sent events, *Result => status, headers, cookies and body, others => the envelope
+func writeResult(ctx context.Context, w http.ResponseWriter, res interface{}) {
+	if val := reflect.ValueOf(res); val.Kind() == reflect.Chan && !val.IsNil() && val.Type().ChanDir()&reflect.RecvDir != 0 {
+		streamChannel(ctx, w, val)
+		return
+	}
+	switch val := res.(type) {
+	case *Result:
+		if val == nil {
+			val = &Result{}
+		}
+		val.write(ctx, w)
+	case Result:
+		val.write(ctx, w)
+	default:
+		(&Result{Body: res}).write(ctx, w)
+	}
+}
+
+func isNilResult(res interface{}) bool {
+	if res == nil {
+		return true
+	}
+	val := reflect.ValueOf(res)
+	switch val.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Chan:
+		return val.IsNil()
+	}
+	return false
+}
+
+func rawRequest(ctx context.Context) *http.Request {
+	if val, ok := ctx.Value(HttpxContext).(*Context); ok && val != nil {
+		return val.r
+	}
+	return nil
+}
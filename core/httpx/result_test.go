@@ -0,0 +1,127 @@
+package httpx
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type resultTestUser struct {
+	Name string `json:"name"`
+}
+
+func serveDIParamHandler(handler interface{}, r *http.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	DIParamHandler(handler)(w, r)
+	return w
+}
+
+func TestHandleResponse_Result(t *testing.T) {
+	w := serveDIParamHandler(func() (*Result, error) {
+		return NewResult(http.StatusCreated, resultTestUser{Name: "trinity"}).
+			WithHeader("X-Request-Id", "1").
+			WithCookie(&http.Cookie{Name: "session", Value: "abc"}), nil
+	}, httptest.NewRequest(http.MethodPost, "/users", nil))
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "1", w.Header().Get("X-Request-Id"))
+	assert.Equal(t, "session=abc", w.Header().Get("Set-Cookie"))
+	var resp Response
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, http.StatusCreated, resp.Status)
+	assert.Equal(t, map[string]interface{}{"name": "trinity"}, resp.Result)
+}
+
+func TestHandleResponse_ResultValue(t *testing.T) {
+	w := serveDIParamHandler(func() Result {
+		return Result{Status: http.StatusAccepted, Body: "ok"}
+	}, httptest.NewRequest(http.MethodPost, "/", nil))
+	assert.Equal(t, http.StatusAccepted, w.Code)
+}
+
+func TestHandleResponse_NilResult(t *testing.T) {
+	w := serveDIParamHandler(func() (*resultTestUser, error) {
+		return nil, nil
+	}, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Empty(t, w.Body.String())
+
+	w = serveDIParamHandler(func() *Result {
+		return nil
+	}, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusNoContent, w.Code)
+}
+
+func TestHandleResponse_NilSliceKeepEnvelope(t *testing.T) {
+	w := serveDIParamHandler(func() ([]resultTestUser, error) {
+		return nil, nil
+	}, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"status":200,"result":null}`, w.Body.String())
+}
+
+func TestHandleResponse_Redirect(t *testing.T) {
+	w := serveDIParamHandler(func() *Result {
+		return Redirect("/login")
+	}, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusFound, w.Code)
+	assert.Equal(t, "/login", w.Header().Get(LocationHeader))
+
+	w = serveDIParamHandler(func() *Result {
+		return Redirect("/v2", http.StatusMovedPermanently)
+	}, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusMovedPermanently, w.Code)
+}
+
+func TestHandleResponse_Reader(t *testing.T) {
+	w := serveDIParamHandler(func() (io.Reader, error) {
+		return strings.NewReader("hello"), nil
+	}, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "hello", w.Body.String())
+	assert.Empty(t, w.Header().Get(ContentDispositionHeader))
+}
+
+func TestHandleResponse_StreamReader(t *testing.T) {
+	w := serveDIParamHandler(func() *File {
+		return &File{Name: "report.csv", Content: io.MultiReader(strings.NewReader("a,b"))}
+	}, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "attachment; filename=report.csv", w.Header().Get(ContentDispositionHeader))
+	assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get(ContentTypeHeader))
+	assert.Equal(t, "a,b", w.Body.String())
+}
+
+func TestHandleResponse_FileRange(t *testing.T) {
+	modTime := time.Date(2021, 10, 3, 0, 0, 0, 0, time.UTC)
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Range", "bytes=0-4")
+	w := serveDIParamHandler(func() (*File, error) {
+		return &File{Name: "hello.txt", Content: strings.NewReader("hello world"), ModTime: modTime, Inline: true}, nil
+	}, r)
+	assert.Equal(t, http.StatusPartialContent, w.Code)
+	assert.Equal(t, "hello", w.Body.String())
+	assert.Equal(t, "bytes 0-4/11", w.Header().Get(ContentRangeHeader))
+	assert.Equal(t, "inline; filename=hello.txt", w.Header().Get(ContentDispositionHeader))
+	assert.Equal(t, modTime.Format(http.TimeFormat), w.Header().Get(LastModifiedHeader))
+}
+
+func TestHandleResponse_OSFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data.json")
+	assert.Nil(t, os.WriteFile(path, []byte(`{"a":1}`), 0o600))
+	w := serveDIParamHandler(func() (*File, error) {
+		f, err := os.Open(path)
+		return &File{Content: f}, err
+	}, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "attachment; filename=data.json", w.Header().Get(ContentDispositionHeader))
+	assert.Equal(t, "application/json", w.Header().Get(ContentTypeHeader))
+	assert.Equal(t, `{"a":1}`, w.Body.String())
+}
@@ -1,7 +1,91 @@
 package httpx
 
-import "github.com/go-playground/validator/v10"
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
 
 var (
-	_defaultValidator = validator.New()
+	_defaultValidator Validator = NewDefaultValidator()
 )
+
+// Validator the validation engine used by Parse
+// after all the params are set, Parse will call StructCtx with the request context
+type Validator interface {
+	StructCtx(ctx context.Context, v interface{}) error
+}
+
+// SetValidator replace the validation engine used by Parse
+func SetValidator(v Validator) {
+	_defaultValidator = v
+}
+
+// GetValidator get the validation engine used by Parse
+func GetValidator() Validator {
+	return _defaultValidator
+}
+
+// DefaultValidator the default validation engine based on go-playground validator
+type DefaultValidator struct {
+	*validator.Validate
+}
+
+func NewDefaultValidator() *DefaultValidator {
+	return &DefaultValidator{
+		Validate: validator.New(),
+	}
+}
+
+// RegisterValidation register the custom validation tag to the default validator
+// the request context will be passed to fn, so the validation could load
+// the session related dependency, e.g. `tenant_exists`
+func RegisterValidation(tag string, fn validator.FuncCtx, callValidationEvenIfNull ...bool) error {
+	v, err := defaultValidate()
+	if err != nil {
+		return err
+	}
+	return v.RegisterValidationCtx(tag, fn, callValidationEvenIfNull...)
+}
+
+// RegisterStructValidation register the struct level validation for the types
+// it is used for the cross field validation
+func RegisterStructValidation(fn validator.StructLevelFuncCtx, types ...interface{}) error {
+	v, err := defaultValidate()
+	if err != nil {
+		return err
+	}
+	v.RegisterStructValidationCtx(fn, types...)
+	return nil
+}
+
+// RegisterTagNameFunc register the func to get the field name in the validation error
+func RegisterTagNameFunc(fn validator.TagNameFunc) error {
+	v, err := defaultValidate()
+	if err != nil {
+		return err
+	}
+	v.RegisterTagNameFunc(fn)
+	return nil
+}
+
+// JSONTagName the tag name func using the json tag as the field name
+// if the json tag not exist or `-` , will fall back to the struct field name
+func JSONTagName(field reflect.StructField) string {
+	name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+	if name == "" || name == "-" {
+		return field.Name
+	}
+	return name
+}
+
+func defaultValidate() (*validator.Validate, error) {
+	v, ok := _defaultValidator.(*DefaultValidator)
+	if !ok {
+		return nil, fmt.Errorf("validator %T not support register, only support the default validator", _defaultValidator)
+	}
+	return v.Validate, nil
+}
@@ -0,0 +1,139 @@
+package httpx
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/codeduckcloud/trinity-go/core/e"
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+)
+
+type tenantCtxKey string
+
+type customValidator struct {
+	called bool
+}
+
+func (c *customValidator) StructCtx(ctx context.Context, v interface{}) error {
+	c.called = true
+	return errors.New("custom validator error")
+}
+
+func withDefaultValidator(t *testing.T) {
+	original := GetValidator()
+	SetValidator(NewDefaultValidator())
+	t.Cleanup(func() {
+		SetValidator(original)
+	})
+}
+
+func TestRegisterValidation(t *testing.T) {
+	withDefaultValidator(t)
+	err := RegisterValidation("tenant_exists", func(ctx context.Context, fl validator.FieldLevel) bool {
+		return ctx.Value(tenantCtxKey("tenant")) == fl.Field().String()
+	})
+	assert.NoError(t, err)
+
+	type args struct {
+		Tenant string `query_param:"tenant" validate:"tenant_exists"`
+	}
+	r := &http.Request{URL: &url.URL{RawQuery: "tenant=codeduck"}}
+	r = r.WithContext(context.WithValue(context.Background(), tenantCtxKey("tenant"), "codeduck"))
+	assert.NoError(t, Parse(r, &args{}))
+
+	r = r.WithContext(context.WithValue(context.Background(), tenantCtxKey("tenant"), "other"))
+	err = Parse(r, &args{})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "tenant_exists")
+}
+
+func TestRegisterStructValidation(t *testing.T) {
+	withDefaultValidator(t)
+	type args struct {
+		Min int `query_param:"min"`
+		Max int `query_param:"max"`
+	}
+	err := RegisterStructValidation(func(ctx context.Context, sl validator.StructLevel) {
+		a := sl.Current().Interface().(args)
+		if a.Min > a.Max {
+			sl.ReportError(a.Min, "Min", "Min", "ltefield", "Max")
+		}
+	}, args{})
+	assert.NoError(t, err)
+
+	r := &http.Request{URL: &url.URL{RawQuery: "min=1&max=2"}}
+	assert.NoError(t, Parse(r, &args{}))
+
+	r = &http.Request{URL: &url.URL{RawQuery: "min=3&max=2"}}
+	err = Parse(r, &args{})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "ltefield")
+}
+
+func TestRegisterTagNameFunc(t *testing.T) {
+	withDefaultValidator(t)
+	assert.NoError(t, RegisterTagNameFunc(JSONTagName))
+	type args struct {
+		PageSize int `query_param:"page_size" json:"page_size" validate:"max=10"`
+	}
+	r := &http.Request{URL: &url.URL{RawQuery: "page_size=20"}}
+	err := Parse(r, &args{})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "'page_size'")
+
+	type address struct {
+		City string `json:"city" validate:"required"`
+	}
+	type createUserRequest struct {
+		Email   string  `json:"email" validate:"required"`
+		Address address `json:"address"`
+	}
+	err = parseParamErr(GetValidator().StructCtx(context.Background(), &createUserRequest{}))
+	assert.Equal(t, []string{"email failed on the 'required' tag", "address.city failed on the 'required' tag"}, e.FromErr(err).Details())
+}
+
+func TestJSONTagName(t *testing.T) {
+	type args struct {
+		A string `json:"a,omitempty"`
+		B string `json:"-"`
+		C string
+	}
+	tests := []struct {
+		field string
+		want  string
+	}{
+		{"A", "a"},
+		{"B", "B"},
+		{"C", "C"},
+	}
+	for _, tt := range tests {
+		f, _ := reflect.TypeOf(args{}).FieldByName(tt.field)
+		assert.Equal(t, tt.want, JSONTagName(f))
+	}
+}
+
+func TestSetValidator(t *testing.T) {
+	original := GetValidator()
+	defer SetValidator(original)
+
+	custom := &customValidator{}
+	SetValidator(custom)
+	assert.Equal(t, custom, GetValidator())
+
+	type args struct {
+		ID int `query_param:"id"`
+	}
+	r := &http.Request{URL: &url.URL{RawQuery: "id=1"}}
+	err := Parse(r, &args{})
+	assert.True(t, custom.called)
+	assert.Error(t, err)
+
+	assert.Error(t, RegisterValidation("sku", func(ctx context.Context, fl validator.FieldLevel) bool { return true }))
+	assert.Error(t, RegisterStructValidation(func(ctx context.Context, sl validator.StructLevel) {}, args{}))
+	assert.Error(t, RegisterTagNameFunc(JSONTagName))
+}
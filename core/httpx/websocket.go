@@ -0,0 +1,54 @@
+package httpx
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+
+	"github.com/codeduckcloud/trinity-go/core/e"
+	"github.com/codeduckcloud/trinity-go/core/wsx"
+)
+
+// upgradeWebSocket upgrade the request after all the params parsed, so that the param error is still responded as http
+func upgradeWebSocket(w http.ResponseWriter, r *http.Request) (*wsx.Conn, error) {
+	conn, err := wsx.Upgrade(w, r)
+	if err != nil {
+		return nil, e.ErrInvalidRequest.Wrap(err)
+	}
+	if val, ok := r.Context().Value(HttpxContext).(*Context); ok && val != nil {
+		val.ws = conn
+	}
+	return conn, nil
+}
+
+func getWebSocket(ctx context.Context) *wsx.Conn {
+	if val, ok := ctx.Value(HttpxContext).(*Context); ok && val != nil {
+		return val.ws
+	}
+	return nil
+}
+
+// CloseWebSocket close the websocket of the request with wsx.CloseInternalServerErr if it is not closed by HandleResponse
+// it should be deferred after InvokeMethod, so that the connection is released when the handler panicked
+func CloseWebSocket(ctx context.Context) {
+	if conn := getWebSocket(ctx); conn != nil {
+		conn.Close(wsx.CloseInternalServerErr, "")
+	}
+}
+
+// closeWebSocket close the connection after the handler returned
+// the error returned is sent as the close reason, 1011 for the internal error, 1008 for others
+func closeWebSocket(ctx context.Context, conn *wsx.Conn, responseValue []reflect.Value) {
+	for _, val := range responseValue {
+		if err, ok := val.Interface().(error); ok && err != nil {
+			status, info := resolveError(ctx, err)
+			code := wsx.ClosePolicyViolation
+			if status >= http.StatusInternalServerError {
+				code = wsx.CloseInternalServerErr
+			}
+			conn.Close(code, info.Message)
+			return
+		}
+	}
+	conn.Close(wsx.CloseNormalClosure, "")
+}
@@ -0,0 +1,96 @@
+package httpx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/codeduckcloud/trinity-go/core/wsx"
+	"github.com/stretchr/testify/assert"
+)
+
+func newWebSocketServer(t *testing.T, handler interface{}) string {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r = r.WithContext(context.WithValue(r.Context(), HttpxContext, NewContext(r, 0)))
+		inParams, err := InvokeMethod(reflect.TypeOf(handler), r, nil, w)
+		if err != nil {
+			HttpResponseErr(r.Context(), w, err)
+			return
+		}
+		HandleResponse(r.Context(), w, reflect.ValueOf(handler).Call(inParams))
+	}))
+	t.Cleanup(server.Close)
+	return "ws" + strings.TrimPrefix(server.URL, "http")
+}
+
+func TestInvokeMethod_WebSocket(t *testing.T) {
+	url := newWebSocketServer(t, func(conn *wsx.Conn, args *struct {
+		Name string `query_param:"name" validate:"required"`
+	}) error {
+		if err := conn.WriteMessage(wsx.TextMessage, []byte(fmt.Sprintf("hello %v", args.Name))); err != nil {
+			return err
+		}
+		_, _, err := conn.ReadMessage()
+		return err
+	})
+	conn, _, err := wsx.Dial(context.Background(), url+"?name=trinity", nil)
+	assert.Nil(t, err)
+	_, msg, err := conn.ReadMessage()
+	assert.Nil(t, err)
+	assert.Equal(t, "hello trinity", string(msg))
+	conn.WriteMessage(wsx.TextMessage, []byte("bye"))
+	_, _, err = conn.ReadMessage()
+	assert.True(t, wsx.IsCloseError(err, wsx.CloseNormalClosure))
+}
+
+func TestInvokeMethod_WebSocketParamError(t *testing.T) {
+	url := newWebSocketServer(t, func(conn *wsx.Conn, args *struct {
+		Name string `query_param:"name" validate:"required"`
+	}) {
+	})
+	_, resp, err := wsx.Dial(context.Background(), url, nil)
+	assert.ErrorIs(t, err, wsx.ErrBadHandshake)
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+}
+
+func TestInvokeMethod_WebSocketHandlerError(t *testing.T) {
+	url := newWebSocketServer(t, func(conn *wsx.Conn) error {
+		return errors.New("database down")
+	})
+	conn, _, err := wsx.Dial(context.Background(), url, nil)
+	assert.Nil(t, err)
+	_, _, err = conn.ReadMessage()
+	assert.True(t, wsx.IsCloseError(err, wsx.CloseInternalServerErr))
+}
+
+func TestInvokeMethod_WebSocketNotUpgrade(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	_, err := InvokeMethod(reflect.TypeOf(func(conn *wsx.Conn) {}), r, nil, httptest.NewRecorder())
+	assert.ErrorIs(t, err, wsx.ErrBadHandshake)
+}
+
+func TestCloseWebSocket_Panic(t *testing.T) {
+	serverConn := make(chan *wsx.Conn, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			recover()
+		}()
+		r = r.WithContext(context.WithValue(r.Context(), HttpxContext, NewContext(r, 0)))
+		inParams, err := InvokeMethod(reflect.TypeOf(func(conn *wsx.Conn) {}), r, nil, w)
+		assert.Nil(t, err)
+		defer CloseWebSocket(r.Context())
+		serverConn <- inParams[0].Interface().(*wsx.Conn)
+		panic("boom")
+	}))
+	defer server.Close()
+	conn, _, err := wsx.Dial(context.Background(), "ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	assert.Nil(t, err)
+	_, _, err = conn.ReadMessage()
+	assert.True(t, wsx.IsCloseError(err, wsx.CloseInternalServerErr))
+	<-(<-serverConn).Done()
+}
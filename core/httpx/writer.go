@@ -0,0 +1,101 @@
+package httpx
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/http"
+)
+
+// ResponseWriter the wrapper of http.ResponseWriter capturing the status and the bytes written
+// the Flusher and the Hijacker of the wrapped writer are kept, the superfluous WriteHeader is ignored
+type ResponseWriter interface {
+	http.ResponseWriter
+	http.Flusher
+	http.Hijacker
+	// the status written, 0 if nothing written
+	Status() int
+	BytesWritten() int64
+	// Written check whether the header is already sent
+	Written() bool
+	// Unwrap used by http.ResponseController
+	Unwrap() http.ResponseWriter
+}
+
+type responseWriter struct {
+	http.ResponseWriter
+	status      int
+	bytes       int64
+	wroteHeader bool
+}
+
+// WrapResponseWriter wrap the writer, the ResponseWriter is returned as it is
+func WrapResponseWriter(w http.ResponseWriter) ResponseWriter {
+	if rw, ok := w.(ResponseWriter); ok {
+		return rw
+	}
+	return &responseWriter{
+		ResponseWriter: w,
+	}
+}
+
+func (w *responseWriter) WriteHeader(status int) {
+	if w.wroteHeader {
+		return
+	}
+	// the informational status is not the final response
+	if status >= 100 && status < 200 && status != http.StatusSwitchingProtocols {
+		w.ResponseWriter.WriteHeader(status)
+		return
+	}
+	w.status = status
+	w.wroteHeader = true
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += int64(n)
+	return n, err
+}
+
+func (w *responseWriter) Flush() {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("response writer %T not support hijack", w.ResponseWriter)
+	}
+	conn, brw, err := hijacker.Hijack()
+	if err == nil && !w.wroteHeader {
+		w.status = http.StatusSwitchingProtocols
+		w.wroteHeader = true
+	}
+	return conn, brw, err
+}
+
+func (w *responseWriter) Status() int {
+	return w.status
+}
+
+func (w *responseWriter) BytesWritten() int64 {
+	return w.bytes
+}
+
+func (w *responseWriter) Written() bool {
+	return w.wroteHeader
+}
+
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
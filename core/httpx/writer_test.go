@@ -0,0 +1,45 @@
+package httpx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWrapResponseWriter(t *testing.T) {
+	rec := httptest.NewRecorder()
+	w := WrapResponseWriter(rec)
+	assert.Equal(t, w, WrapResponseWriter(w))
+	assert.False(t, w.Written())
+	assert.Equal(t, 0, w.Status())
+
+	w.WriteHeader(http.StatusCreated)
+	w.WriteHeader(http.StatusInternalServerError)
+	n, err := w.Write([]byte("hello"))
+	assert.Nil(t, err)
+	assert.Equal(t, 5, n)
+	assert.True(t, w.Written())
+	assert.Equal(t, http.StatusCreated, w.Status())
+	assert.Equal(t, int64(5), w.BytesWritten())
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, rec, w.Unwrap())
+}
+
+func TestWrapResponseWriter_ImplicitStatus(t *testing.T) {
+	rec := httptest.NewRecorder()
+	w := WrapResponseWriter(rec)
+	w.WriteHeader(http.StatusContinue)
+	assert.False(t, w.Written())
+	w.Flush()
+	assert.True(t, rec.Flushed)
+	assert.Equal(t, http.StatusOK, w.Status())
+}
+
+func TestWrapResponseWriter_Hijack(t *testing.T) {
+	w := WrapResponseWriter(httptest.NewRecorder())
+	_, _, err := w.Hijack()
+	assert.NotNil(t, err)
+	assert.False(t, w.Written())
+}
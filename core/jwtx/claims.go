@@ -0,0 +1,126 @@
+package jwtx
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+type claimsContextKey string
+
+const (
+	ClaimsContext claimsContextKey = "JWTX_CLAIMS_KEY"
+)
+
+// the registered claim names, see RFC 7519 4.1
+const (
+	ClaimIssuer    = "iss"
+	ClaimSubject   = "sub"
+	ClaimAudience  = "aud"
+	ClaimExpiresAt = "exp"
+	ClaimNotBefore = "nbf"
+	ClaimIssuedAt  = "iat"
+	ClaimID        = "jti"
+)
+
+// Claims the claims of the token, the numbers are decoded as json.Number
+type Claims map[string]interface{}
+
+// WithClaims set the claims in the ctx
+func WithClaims(ctx context.Context, claims Claims) context.Context {
+	return context.WithValue(ctx, ClaimsContext, claims)
+}
+
+// ClaimsFromCtx get the claims verified by the middleware.Jwt
+func ClaimsFromCtx(ctx context.Context) (Claims, bool) {
+	claims, ok := ctx.Value(ClaimsContext).(Claims)
+	return claims, ok
+}
+
+func (c Claims) Issuer() string {
+	return c.String(ClaimIssuer)
+}
+
+func (c Claims) Subject() string {
+	return c.String(ClaimSubject)
+}
+
+func (c Claims) ID() string {
+	return c.String(ClaimID)
+}
+
+// Audience the aud claim, which could be the single string or the array, see RFC 7519 4.1.3
+func (c Claims) Audience() []string {
+	return c.Strings(ClaimAudience)
+}
+
+func (c Claims) ExpiresAt() (time.Time, bool) {
+	return c.Time(ClaimExpiresAt)
+}
+
+func (c Claims) NotBefore() (time.Time, bool) {
+	return c.Time(ClaimNotBefore)
+}
+
+func (c Claims) IssuedAt() (time.Time, bool) {
+	return c.Time(ClaimIssuedAt)
+}
+
+// String get the string claim, return empty if not exist or not string
+func (c Claims) String(key string) string {
+	val, _ := c[key].(string)
+	return val
+}
+
+// Strings get the string list claim, the string claim is the single value
+func (c Claims) Strings(key string) []string {
+	switch val := c[key].(type) {
+	case string:
+		if val == "" {
+			return nil
+		}
+		return []string{val}
+	case []string:
+		return val
+	case []interface{}:
+		res := make([]string, 0, len(val))
+		for _, item := range val {
+			if s, ok := item.(string); ok {
+				res = append(res, s)
+			}
+		}
+		return res
+	}
+	return nil
+}
+
+// Fields get the string list claim, the string claim is split by the space, e.g. the `scope` claim of RFC 8693
+func (c Claims) Fields(key string) []string {
+	if val, ok := c[key].(string); ok {
+		return strings.Fields(val)
+	}
+	return c.Strings(key)
+}
+
+// Time get the NumericDate claim
+func (c Claims) Time(key string) (time.Time, bool) {
+	var seconds float64
+	switch val := c[key].(type) {
+	case json.Number:
+		f, err := val.Float64()
+		if err != nil {
+			return time.Time{}, false
+		}
+		seconds = f
+	case float64:
+		seconds = val
+	case int64:
+		seconds = float64(val)
+	case int:
+		seconds = float64(val)
+	default:
+		return time.Time{}, false
+	}
+	return time.Unix(0, int64(seconds*float64(time.Second))), true
+}
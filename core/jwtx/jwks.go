@@ -0,0 +1,319 @@
+package jwtx
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultJWKSRefreshInterval = time.Hour
+	// the min interval to refresh the keys when the unknown kid found, to avoid the refresh storm
+	DefaultJWKSMinRefreshInterval = 10 * time.Second
+	// the timeout of fetching the JWKS url
+	DefaultJWKSFetchTimeout = 10 * time.Second
+)
+
+var (
+	_jwksClient = &http.Client{Timeout: DefaultJWKSFetchTimeout}
+)
+
+// JWK the json web key, see RFC 7517
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid,omitempty"`
+	Use       string `json:"use,omitempty"`
+	Algorithm string `json:"alg,omitempty"`
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// EC
+	Curve string `json:"crv,omitempty"`
+	X     string `json:"x,omitempty"`
+	Y     string `json:"y,omitempty"`
+	// oct
+	K string `json:"k,omitempty"`
+}
+
+// JWKS the json web key set
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// KeySet the cached keys loaded from the JWKS file or url
+// the keys are reloaded by the refresh interval, or when the unknown kid found, so that the key rotation is supported
+// the reloads are limited by the min refresh interval, the cached keys are kept if the reload failed
+type KeySet struct {
+	mu                 sync.RWMutex
+	keys               map[string]interface{}
+	load               func(ctx context.Context) ([]byte, error)
+	refreshInterval    time.Duration
+	minRefreshInterval time.Duration
+	fetchedAt          time.Time
+	attemptedAt        time.Time
+	refreshing         *refreshCall
+	now                func() time.Time
+}
+
+// refreshCall the in-flight refresh shared by the concurrent callers
+type refreshCall struct {
+	done chan struct{}
+	err  error
+}
+
+func newKeySet(load func(ctx context.Context) ([]byte, error), refreshInterval time.Duration) *KeySet {
+	if refreshInterval <= 0 {
+		refreshInterval = DefaultJWKSRefreshInterval
+	}
+	return &KeySet{
+		keys:               make(map[string]interface{}),
+		load:               load,
+		refreshInterval:    refreshInterval,
+		minRefreshInterval: DefaultJWKSMinRefreshInterval,
+		now:                time.Now,
+	}
+}
+
+// NewRemoteKeySet new the key set loaded from the url, the keys are loaded lazily
+func NewRemoteKeySet(url string, refreshInterval time.Duration) *KeySet {
+	return newKeySet(func(ctx context.Context) ([]byte, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := _jwksClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("jwks url %v responded status %v", url, resp.StatusCode)
+		}
+		return io.ReadAll(resp.Body)
+	}, refreshInterval)
+}
+
+// LoadKeySetFile load the key set from the file, the file is reloaded by the refresh interval
+func LoadKeySetFile(path string, refreshInterval ...time.Duration) (*KeySet, error) {
+	interval := DefaultJWKSRefreshInterval
+	if len(refreshInterval) > 0 {
+		interval = refreshInterval[0]
+	}
+	k := newKeySet(func(ctx context.Context) ([]byte, error) {
+		return os.ReadFile(path)
+	}, interval)
+	if err := k.Refresh(context.Background()); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+// Key get the key by the kid
+// if the kid is empty and there is only one key, the key is returned
+func (k *KeySet) Key(ctx context.Context, kid string) (interface{}, error) {
+	k.mu.RLock()
+	stale := k.now().Sub(k.fetchedAt) >= k.refreshInterval
+	k.mu.RUnlock()
+	if stale && k.canRefresh() {
+		if err := k.Refresh(ctx); err != nil && k.Len() == 0 {
+			return nil, err
+		}
+	}
+	if key, ok := k.lookup(kid); ok {
+		return key, nil
+	}
+	if k.canRefresh() {
+		if err := k.Refresh(ctx); err != nil {
+			return nil, fmt.Errorf("%w: kid %v, refresh error: %v", ErrKeyNotFound, kid, err)
+		}
+		if key, ok := k.lookup(kid); ok {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: kid %v", ErrKeyNotFound, kid)
+}
+
+// Refresh reload the keys, the concurrent calls share the same reload
+func (k *KeySet) Refresh(ctx context.Context) error {
+	k.mu.Lock()
+	if call := k.refreshing; call != nil {
+		k.mu.Unlock()
+		select {
+		case <-call.done:
+			return call.err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	call := &refreshCall{done: make(chan struct{})}
+	k.refreshing = call
+	k.attemptedAt = k.now()
+	k.mu.Unlock()
+
+	// the reload is shared, so it is not canceled by the caller
+	call.err = k.refresh(context.WithoutCancel(ctx))
+	k.mu.Lock()
+	k.refreshing = nil
+	k.mu.Unlock()
+	close(call.done)
+	return call.err
+}
+
+func (k *KeySet) refresh(ctx context.Context) error {
+	data, err := k.load(ctx)
+	if err != nil {
+		return fmt.Errorf("jwks load error, err: %w", err)
+	}
+	keys, err := ParseJWKS(data)
+	if err != nil {
+		return err
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.keys = keys
+	k.fetchedAt = k.now()
+	return nil
+}
+
+// Len the number of the keys
+func (k *KeySet) Len() int {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return len(k.keys)
+}
+
+// canRefresh check whether the min refresh interval passed since the last attempt
+func (k *KeySet) canRefresh() bool {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.now().Sub(k.attemptedAt) >= k.minRefreshInterval
+}
+
+func (k *KeySet) lookup(kid string) (interface{}, bool) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	if key, ok := k.keys[kid]; ok {
+		return key, true
+	}
+	if kid == "" && len(k.keys) == 1 {
+		for _, key := range k.keys {
+			return key, true
+		}
+	}
+	return nil, false
+}
+
+// ParseJWKS parse the JWKS into the keys by the kid
+// RSA => *rsa.PublicKey, EC => *ecdsa.PublicKey, oct => []byte
+// the unsupported or invalid keys are skipped, the error is returned only if no key is usable
+func ParseJWKS(data []byte) (map[string]interface{}, error) {
+	var set JWKS
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("jwks decode error, err: %w", err)
+	}
+	keys := make(map[string]interface{}, len(set.Keys))
+	var keyErr error
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.PublicKey()
+		if err != nil {
+			if keyErr == nil {
+				keyErr = err
+			}
+			continue
+		}
+		keys[jwk.KeyID] = key
+	}
+	if len(keys) == 0 && keyErr != nil {
+		return nil, keyErr
+	}
+	return keys, nil
+}
+
+// PublicKey decode the key
+func (j JWK) PublicKey() (interface{}, error) {
+	switch j.KeyType {
+	case "RSA":
+		n, err := decodeBigInt(j.N)
+		if err != nil {
+			return nil, fmt.Errorf("jwk %v decode n error, err: %w", j.KeyID, err)
+		}
+		e, err := decodeBigInt(j.E)
+		if err != nil {
+			return nil, fmt.Errorf("jwk %v decode e error, err: %w", j.KeyID, err)
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch j.Curve {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("jwk %v curve %v not supported", j.KeyID, j.Curve)
+		}
+		x, err := decodeBigInt(j.X)
+		if err != nil {
+			return nil, fmt.Errorf("jwk %v decode x error, err: %w", j.KeyID, err)
+		}
+		y, err := decodeBigInt(j.Y)
+		if err != nil {
+			return nil, fmt.Errorf("jwk %v decode y error, err: %w", j.KeyID, err)
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "oct":
+		return base64.RawURLEncoding.DecodeString(j.K)
+	default:
+		return nil, fmt.Errorf("jwk %v key type %v not supported", j.KeyID, j.KeyType)
+	}
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+// NewJWK encode the public key as the JWK, it is used to publish the JWKS
+func NewJWK(kid string, key crypto.PublicKey) (JWK, error) {
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		return JWK{
+			KeyType: "RSA",
+			KeyID:   kid,
+			Use:     "sig",
+			N:       base64.RawURLEncoding.EncodeToString(k.N.Bytes()),
+			E:       base64.RawURLEncoding.EncodeToString(big.NewInt(int64(k.E)).Bytes()),
+		}, nil
+	case *ecdsa.PublicKey:
+		size := (k.Curve.Params().BitSize + 7) / 8
+		return JWK{
+			KeyType: "EC",
+			KeyID:   kid,
+			Use:     "sig",
+			Curve:   k.Curve.Params().Name,
+			X:       base64.RawURLEncoding.EncodeToString(k.X.FillBytes(make([]byte, size))),
+			Y:       base64.RawURLEncoding.EncodeToString(k.Y.FillBytes(make([]byte, size))),
+		}, nil
+	default:
+		return JWK{}, fmt.Errorf("jwk key type %T not supported", key)
+	}
+}
@@ -0,0 +1,181 @@
+package jwtx
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestJWKS(t *testing.T, keys map[string]interface{}) []byte {
+	set := JWKS{}
+	for kid, key := range keys {
+		var jwk JWK
+		var err error
+		switch k := key.(type) {
+		case *rsa.PrivateKey:
+			jwk, err = NewJWK(kid, &k.PublicKey)
+		case *ecdsa.PrivateKey:
+			jwk, err = NewJWK(kid, &k.PublicKey)
+		}
+		assert.Nil(t, err)
+		set.Keys = append(set.Keys, jwk)
+	}
+	b, err := json.Marshal(set)
+	assert.Nil(t, err)
+	return b
+}
+
+func TestJWKS_File(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	assert.Nil(t, os.WriteFile(path, newTestJWKS(t, map[string]interface{}{"rsa": rsaKey, "ec": ecKey}), 0o600))
+	v, err := NewVerifier(Config{JWKSFile: path})
+	assert.Nil(t, err)
+	for kid, key := range map[string]interface{}{"rsa": rsaKey, "ec": ecKey} {
+		alg := RS256
+		if kid == "ec" {
+			alg = ES256
+		}
+		token, err := Sign(Claims{ClaimSubject: kid}, alg, key, kid)
+		assert.Nil(t, err)
+		claims, err := v.Verify(context.Background(), token)
+		assert.Nil(t, err)
+		assert.Equal(t, kid, claims.Subject())
+	}
+}
+
+func TestJWKS_URLRotation(t *testing.T) {
+	oldKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	newKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	var (
+		mu      sync.Mutex
+		current = newTestJWKS(t, map[string]interface{}{"old": oldKey})
+		fetched int32
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&fetched, 1)
+		mu.Lock()
+		defer mu.Unlock()
+		w.Write(current)
+	}))
+	defer server.Close()
+	v, err := NewVerifier(Config{JWKSURL: server.URL})
+	assert.Nil(t, err)
+	v.jwks.minRefreshInterval = 0
+
+	token, _ := Sign(Claims{}, RS256, oldKey, "old")
+	_, err = v.Verify(context.Background(), token)
+	assert.Nil(t, err)
+	_, err = v.Verify(context.Background(), token)
+	assert.Nil(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&fetched), "the keys should be cached")
+
+	mu.Lock()
+	current = newTestJWKS(t, map[string]interface{}{"new": newKey})
+	mu.Unlock()
+	token, _ = Sign(Claims{}, RS256, newKey, "new")
+	_, err = v.Verify(context.Background(), token)
+	assert.Nil(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&fetched))
+}
+
+func TestKeySet_RefreshInterval(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	var loaded int32
+	k := newKeySet(func(ctx context.Context) ([]byte, error) {
+		atomic.AddInt32(&loaded, 1)
+		return newTestJWKS(t, map[string]interface{}{"ec": key}), nil
+	}, time.Minute)
+	now := time.Now()
+	k.now = func() time.Time { return now }
+	_, err := k.Key(context.Background(), "")
+	assert.Nil(t, err, "the only key is used for the token without kid")
+	_, err = k.Key(context.Background(), "unknown")
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&loaded), "the refresh is limited by the min refresh interval")
+
+	now = now.Add(2 * time.Minute)
+	_, err = k.Key(context.Background(), "ec")
+	assert.Nil(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&loaded))
+}
+
+func TestKeySet_RefreshFailed(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	var (
+		loaded int32
+		failed atomic.Bool
+	)
+	k := newKeySet(func(ctx context.Context) ([]byte, error) {
+		atomic.AddInt32(&loaded, 1)
+		if failed.Load() {
+			return nil, errors.New("connection refused")
+		}
+		return newTestJWKS(t, map[string]interface{}{"ec": key}), nil
+	}, time.Minute)
+	now := time.Now()
+	k.now = func() time.Time { return now }
+	_, err := k.Key(context.Background(), "ec")
+	assert.Nil(t, err)
+
+	failed.Store(true)
+	now = now.Add(2 * time.Minute)
+	for i := 0; i < 3; i++ {
+		_, err = k.Key(context.Background(), "ec")
+		assert.Nil(t, err, "the cached keys are used if the refresh failed")
+	}
+	assert.Equal(t, int32(2), atomic.LoadInt32(&loaded), "the stale refresh is limited by the min refresh interval")
+}
+
+func TestKeySet_ConcurrentRefresh(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	var loaded int32
+	release := make(chan struct{})
+	k := newKeySet(func(ctx context.Context) ([]byte, error) {
+		atomic.AddInt32(&loaded, 1)
+		<-release
+		return newTestJWKS(t, map[string]interface{}{"ec": key}), nil
+	}, time.Minute)
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.Nil(t, k.Refresh(context.Background()))
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&loaded))
+	assert.Equal(t, 1, k.Len())
+}
+
+func TestParseJWKS(t *testing.T) {
+	keys, err := ParseJWKS([]byte(`{"keys":[{"kty":"oct","kid":"hs","k":"c2VjcmV0"},{"kty":"RSA","kid":"enc","use":"enc"},{"kty":"OKP","kid":"ed","crv":"Ed25519","x":"11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"},{"kty":"EC","kid":"p1","crv":"P-1"}]}`))
+	assert.Nil(t, err, "the unsupported keys are skipped")
+	assert.Equal(t, []byte("secret"), keys["hs"])
+	assert.NotContains(t, keys, "enc")
+	assert.NotContains(t, keys, "ed")
+	assert.NotContains(t, keys, "p1")
+
+	for _, data := range []string{`x`, `{"keys":[{"kty":"foo"}]}`, `{"keys":[{"kty":"EC","crv":"P-1"}]}`, `{"keys":[{"kty":"RSA","n":"!!"}]}`} {
+		_, err := ParseJWKS([]byte(data))
+		assert.NotNil(t, err, data)
+	}
+}
@@ -0,0 +1,294 @@
+package jwtx
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+)
+
+const (
+	HS256 = "HS256"
+	RS256 = "RS256"
+	ES256 = "ES256"
+)
+
+var (
+	ErrTokenMalformed       = errors.New("token is malformed")
+	ErrUnsupportedAlgorithm = errors.New("token algorithm is not supported")
+	ErrKeyNotFound          = errors.New("token key not found")
+	ErrInvalidSignature     = errors.New("token signature is invalid")
+	ErrTokenExpired         = errors.New("token is expired")
+	ErrTokenNotValidYet     = errors.New("token is not valid yet")
+	ErrInvalidIssuer        = errors.New("token issuer is invalid")
+	ErrInvalidAudience      = errors.New("token audience is invalid")
+)
+
+// Header the JOSE header of the token
+type Header struct {
+	Algorithm string `json:"alg"`
+	Type      string `json:"typ,omitempty"`
+	KeyID     string `json:"kid,omitempty"`
+}
+
+// Config the config of the Verifier
+type Config struct {
+	// the secret of HS256
+	Secret []byte
+	// the public keys of RS256 / ES256 by the kid, the key of "" is used for the token without kid
+	Keys map[string]crypto.PublicKey
+	// the JWKS file path
+	JWKSFile string
+	// the JWKS url, the keys are cached and refreshed by the interval or when the unknown kid found
+	JWKSURL string
+	// default: DefaultJWKSRefreshInterval
+	JWKSRefreshInterval time.Duration
+	// the allowed algorithms, default: all the algorithms with the key configured
+	Algorithms []string
+	// the iss claim should be equal to the issuer if set
+	Issuer string
+	// the aud claim should contain one of the audience if set
+	Audience []string
+	// the clock skew allowed when validate exp and nbf
+	Leeway time.Duration
+	// default: time.Now
+	Now func() time.Time
+}
+
+// Verifier verify the token signature and the registered claims
+type Verifier struct {
+	config     Config
+	algorithms map[string]bool
+	jwks       *KeySet
+}
+
+func NewVerifier(c Config) (*Verifier, error) {
+	if c.Now == nil {
+		c.Now = time.Now
+	}
+	v := &Verifier{
+		config:     c,
+		algorithms: make(map[string]bool),
+	}
+	switch {
+	case c.JWKSURL != "":
+		v.jwks = NewRemoteKeySet(c.JWKSURL, c.JWKSRefreshInterval)
+	case c.JWKSFile != "":
+		jwks, err := LoadKeySetFile(c.JWKSFile, c.JWKSRefreshInterval)
+		if err != nil {
+			return nil, err
+		}
+		v.jwks = jwks
+	}
+	algorithms := c.Algorithms
+	if len(algorithms) == 0 {
+		if len(c.Secret) > 0 {
+			algorithms = append(algorithms, HS256)
+		}
+		if len(c.Keys) > 0 || v.jwks != nil {
+			algorithms = append(algorithms, RS256, ES256)
+		}
+	}
+	if len(algorithms) == 0 {
+		return nil, fmt.Errorf("jwtx: no key configured")
+	}
+	for _, alg := range algorithms {
+		switch alg {
+		case HS256, RS256, ES256:
+			v.algorithms[alg] = true
+		default:
+			return nil, fmt.Errorf("jwtx: algorithm %v not supported", alg)
+		}
+	}
+	return v, nil
+}
+
+// Verify verify the token and return the claims
+func (v *Verifier) Verify(ctx context.Context, token string) (Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrTokenMalformed
+	}
+	var header Header
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("%w: header %v", ErrTokenMalformed, err)
+	}
+	if !v.algorithms[header.Algorithm] {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedAlgorithm, header.Algorithm)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: signature %v", ErrTokenMalformed, err)
+	}
+	key, err := v.key(ctx, header)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifySignature(header.Algorithm, key, []byte(parts[0]+"."+parts[1]), signature); err != nil {
+		return nil, err
+	}
+	claims := Claims{}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("%w: claims %v", ErrTokenMalformed, err)
+	}
+	if err := v.validate(claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+func (v *Verifier) key(ctx context.Context, header Header) (interface{}, error) {
+	if header.Algorithm == HS256 {
+		if len(v.config.Secret) == 0 {
+			return nil, ErrKeyNotFound
+		}
+		return v.config.Secret, nil
+	}
+	if key, ok := v.config.Keys[header.KeyID]; ok {
+		return key, nil
+	}
+	if v.jwks != nil {
+		return v.jwks.Key(ctx, header.KeyID)
+	}
+	return nil, fmt.Errorf("%w: kid %v", ErrKeyNotFound, header.KeyID)
+}
+
+func (v *Verifier) validate(claims Claims) error {
+	now := v.config.Now()
+	leeway := v.config.Leeway
+	if exp, ok := claims.ExpiresAt(); ok && !now.Before(exp.Add(leeway)) {
+		return ErrTokenExpired
+	}
+	if nbf, ok := claims.NotBefore(); ok && now.Add(leeway).Before(nbf) {
+		return ErrTokenNotValidYet
+	}
+	if v.config.Issuer != "" && claims.Issuer() != v.config.Issuer {
+		return fmt.Errorf("%w: %v", ErrInvalidIssuer, claims.Issuer())
+	}
+	if len(v.config.Audience) > 0 && !containsAny(claims.Audience(), v.config.Audience) {
+		return fmt.Errorf("%w: %v", ErrInvalidAudience, claims.Audience())
+	}
+	return nil
+}
+
+// Sign sign the claims
+// key: []byte for HS256, *rsa.PrivateKey for RS256, *ecdsa.PrivateKey for ES256
+func Sign(claims Claims, alg string, key interface{}, kid string) (string, error) {
+	header, err := json.Marshal(Header{Algorithm: alg, Type: "JWT", KeyID: kid})
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signingInput))
+	var signature []byte
+	switch alg {
+	case HS256:
+		secret, ok := key.([]byte)
+		if !ok {
+			return "", fmt.Errorf("jwtx: HS256 key should be []byte, actual: %T", key)
+		}
+		mac := hmac.New(sha256.New, secret)
+		mac.Write([]byte(signingInput))
+		signature = mac.Sum(nil)
+	case RS256:
+		privateKey, ok := key.(*rsa.PrivateKey)
+		if !ok {
+			return "", fmt.Errorf("jwtx: RS256 key should be *rsa.PrivateKey, actual: %T", key)
+		}
+		signature, err = rsa.SignPKCS1v15(rand.Reader, privateKey, crypto.SHA256, digest[:])
+		if err != nil {
+			return "", err
+		}
+	case ES256:
+		privateKey, ok := key.(*ecdsa.PrivateKey)
+		if !ok {
+			return "", fmt.Errorf("jwtx: ES256 key should be *ecdsa.PrivateKey, actual: %T", key)
+		}
+		r, s, err := ecdsa.Sign(rand.Reader, privateKey, digest[:])
+		if err != nil {
+			return "", err
+		}
+		signature = make([]byte, 64)
+		r.FillBytes(signature[:32])
+		s.FillBytes(signature[32:])
+	default:
+		return "", fmt.Errorf("%w: %v", ErrUnsupportedAlgorithm, alg)
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+func verifySignature(alg string, key interface{}, signingInput []byte, signature []byte) error {
+	digest := sha256.Sum256(signingInput)
+	switch alg {
+	case HS256:
+		secret, ok := key.([]byte)
+		if !ok {
+			return fmt.Errorf("%w: HS256 key type %T", ErrKeyNotFound, key)
+		}
+		mac := hmac.New(sha256.New, secret)
+		mac.Write(signingInput)
+		if !hmac.Equal(signature, mac.Sum(nil)) {
+			return ErrInvalidSignature
+		}
+	case RS256:
+		publicKey, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("%w: RS256 key type %T", ErrKeyNotFound, key)
+		}
+		if err := rsa.VerifyPKCS1v15(publicKey, crypto.SHA256, digest[:], signature); err != nil {
+			return ErrInvalidSignature
+		}
+	case ES256:
+		publicKey, ok := key.(*ecdsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("%w: ES256 key type %T", ErrKeyNotFound, key)
+		}
+		if len(signature) != 64 {
+			return ErrInvalidSignature
+		}
+		r := new(big.Int).SetBytes(signature[:32])
+		s := new(big.Int).SetBytes(signature[32:])
+		if !ecdsa.Verify(publicKey, digest[:], r, s) {
+			return ErrInvalidSignature
+		}
+	default:
+		return fmt.Errorf("%w: %v", ErrUnsupportedAlgorithm, alg)
+	}
+	return nil
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	return d.Decode(v)
+}
+
+func containsAny(values []string, targets []string) bool {
+	for _, value := range values {
+		for _, target := range targets {
+			if value == target {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,151 @@
+package jwtx
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	testSecret = []byte("secret")
+	testNow    = time.Unix(1700000000, 0)
+)
+
+func testClaims() Claims {
+	return Claims{
+		ClaimSubject:   "user-1",
+		ClaimIssuer:    "https://auth.example.com",
+		ClaimAudience:  []string{"api"},
+		ClaimExpiresAt: testNow.Add(time.Hour).Unix(),
+		ClaimNotBefore: testNow.Add(-time.Minute).Unix(),
+		"roles":        []string{"admin"},
+	}
+}
+
+func TestVerify_Algorithms(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+	v, err := NewVerifier(Config{
+		Secret: testSecret,
+		Keys: map[string]crypto.PublicKey{
+			"rsa": &rsaKey.PublicKey,
+			"ec":  &ecKey.PublicKey,
+		},
+		Now: func() time.Time { return testNow },
+	})
+	assert.Nil(t, err)
+	tests := []struct {
+		alg string
+		key interface{}
+		kid string
+	}{
+		{alg: HS256, key: testSecret},
+		{alg: RS256, key: rsaKey, kid: "rsa"},
+		{alg: ES256, key: ecKey, kid: "ec"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.alg, func(t *testing.T) {
+			token, err := Sign(testClaims(), tt.alg, tt.key, tt.kid)
+			assert.Nil(t, err)
+			claims, err := v.Verify(context.Background(), token)
+			assert.Nil(t, err)
+			assert.Equal(t, "user-1", claims.Subject())
+			assert.Equal(t, []string{"api"}, claims.Audience())
+			assert.Equal(t, []string{"admin"}, claims.Strings("roles"))
+
+			// tamper the payload
+			parts := strings.Split(token, ".")
+			other, _ := Sign(Claims{ClaimSubject: "user-2"}, tt.alg, tt.key, tt.kid)
+			_, err = v.Verify(context.Background(), parts[0]+"."+strings.Split(other, ".")[1]+"."+parts[2])
+			assert.ErrorIs(t, err, ErrInvalidSignature)
+		})
+	}
+	token, _ := Sign(testClaims(), RS256, rsaKey, "unknown")
+	_, err = v.Verify(context.Background(), token)
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+}
+
+func TestVerify_Claims(t *testing.T) {
+	v, err := NewVerifier(Config{
+		Secret:   testSecret,
+		Issuer:   "https://auth.example.com",
+		Audience: []string{"api", "web"},
+		Leeway:   time.Minute,
+		Now:      func() time.Time { return testNow },
+	})
+	assert.Nil(t, err)
+	tests := []struct {
+		name    string
+		modify  func(c Claims)
+		wantErr error
+	}{
+		{name: "valid", modify: func(c Claims) {}},
+		{name: "expired", modify: func(c Claims) { c[ClaimExpiresAt] = testNow.Add(-2 * time.Minute).Unix() }, wantErr: ErrTokenExpired},
+		{name: "expired in leeway", modify: func(c Claims) { c[ClaimExpiresAt] = testNow.Add(-30 * time.Second).Unix() }},
+		{name: "not valid yet", modify: func(c Claims) { c[ClaimNotBefore] = testNow.Add(2 * time.Minute).Unix() }, wantErr: ErrTokenNotValidYet},
+		{name: "issuer", modify: func(c Claims) { c[ClaimIssuer] = "evil" }, wantErr: ErrInvalidIssuer},
+		{name: "audience", modify: func(c Claims) { c[ClaimAudience] = "other" }, wantErr: ErrInvalidAudience},
+		{name: "audience string", modify: func(c Claims) { c[ClaimAudience] = "web" }},
+		{name: "audience string with space", modify: func(c Claims) { c[ClaimAudience] = "other api" }, wantErr: ErrInvalidAudience},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := testClaims()
+			tt.modify(claims)
+			token, err := Sign(claims, HS256, testSecret, "")
+			assert.Nil(t, err)
+			_, err = v.Verify(context.Background(), token)
+			if tt.wantErr == nil {
+				assert.Nil(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestVerify_Malformed(t *testing.T) {
+	v, err := NewVerifier(Config{Secret: testSecret})
+	assert.Nil(t, err)
+	for _, token := range []string{"", "a.b", "!!.b.c", "eyJhbGciOiJIUzI1NiJ9.b.!!"} {
+		_, err := v.Verify(context.Background(), token)
+		assert.ErrorIs(t, err, ErrTokenMalformed, token)
+	}
+	// alg none is never allowed
+	_, err = v.Verify(context.Background(), "eyJhbGciOiJub25lIn0.e30.")
+	assert.ErrorIs(t, err, ErrUnsupportedAlgorithm)
+}
+
+func TestNewVerifier_Error(t *testing.T) {
+	_, err := NewVerifier(Config{})
+	assert.NotNil(t, err)
+	_, err = NewVerifier(Config{Secret: testSecret, Algorithms: []string{"HS512"}})
+	assert.NotNil(t, err)
+	_, err = NewVerifier(Config{JWKSFile: "not-exist.json"})
+	assert.NotNil(t, err)
+}
+
+func TestClaims(t *testing.T) {
+	ctx := WithClaims(context.Background(), Claims{"scope": "read write", ClaimIssuedAt: 1.5})
+	claims, ok := ClaimsFromCtx(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, []string{"read", "write"}, claims.Fields("scope"))
+	assert.Equal(t, []string{"read write"}, claims.Strings("scope"))
+	iat, ok := claims.IssuedAt()
+	assert.True(t, ok)
+	assert.Equal(t, int64(1500), iat.UnixMilli())
+	_, ok = claims.ExpiresAt()
+	assert.False(t, ok)
+	_, ok = ClaimsFromCtx(context.Background())
+	assert.False(t, ok)
+}
@@ -22,6 +22,13 @@ func FromCtx(ctx context.Context) Logger {
 	return log
 }
 
+// TryFromCtx get the logger from ctx
+// return false if the logger not exist
+func TryFromCtx(ctx context.Context) (Logger, bool) {
+	log, ok := ctx.Value(LoggerContext).(Logger)
+	return log, ok
+}
+
 func NewCtx(log Logger) context.Context {
 	return WithCtx(context.Background(), log)
 }
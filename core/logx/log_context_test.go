@@ -22,3 +22,14 @@ func TestFromCtx_Panic(t *testing.T) {
 		FromCtx(context.Background())
 	})
 }
+
+func TestTryFromCtx(t *testing.T) {
+	logger := NewLogrusLogger()
+	got, ok := TryFromCtx(NewCtx(logger))
+	assert.True(t, ok)
+	assert.Equal(t, logger, got)
+
+	got, ok = TryFromCtx(context.Background())
+	assert.False(t, ok)
+	assert.Nil(t, got)
+}
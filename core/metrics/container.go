@@ -0,0 +1,50 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/codeduckcloud/trinity-go/core/container"
+)
+
+const (
+	ResultSuccess = "success"
+	ResultFailed  = "failed"
+)
+
+var _ container.Observer = new(ContainerObserver)
+
+// ContainerObserver collect the metrics of the container, set it by container.SetObserver
+type ContainerObserver struct {
+	gets       *Counter
+	releases   *Counter
+	selfChecks *Histogram
+}
+
+// NewContainerObserver new the observer with the metrics registered in the registry
+//
+//	trinity_container_get_total{instance}
+//	trinity_container_release_total{instance}
+//	trinity_container_self_check_duration_seconds{instance,result}
+func NewContainerObserver(registry *Registry) *ContainerObserver {
+	return &ContainerObserver{
+		gets:       GetOrRegister(registry, NewCounter("trinity_container_get_total", "The number of the instances got from the pool.", "instance")),
+		releases:   GetOrRegister(registry, NewCounter("trinity_container_release_total", "The number of the instances released to the pool.", "instance")),
+		selfChecks: GetOrRegister(registry, NewHistogram("trinity_container_self_check_duration_seconds", "The duration of the instance self check.", nil, "instance", "result")),
+	}
+}
+
+func (o *ContainerObserver) OnGet(instanceName container.InstanceName) {
+	o.gets.Inc(string(instanceName))
+}
+
+func (o *ContainerObserver) OnRelease(instanceName container.InstanceName) {
+	o.releases.Inc(string(instanceName))
+}
+
+func (o *ContainerObserver) OnSelfCheck(instanceName container.InstanceName, duration time.Duration, err error) {
+	result := ResultSuccess
+	if err != nil {
+		result = ResultFailed
+	}
+	o.selfChecks.Observe(duration.Seconds(), string(instanceName), result)
+}
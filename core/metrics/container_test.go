@@ -0,0 +1,28 @@
+package metrics
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContainerObserver(t *testing.T) {
+	r := NewRegistry()
+	o := NewContainerObserver(r)
+	o.OnGet("UserService")
+	o.OnGet("UserService")
+	o.OnRelease("UserService")
+	o.OnSelfCheck("UserService", 2*time.Millisecond, nil)
+	o.OnSelfCheck("OrderService", time.Millisecond, errors.New("inject failed"))
+
+	buf := &bytes.Buffer{}
+	assert.NoError(t, r.WriteText(buf))
+	text := buf.String()
+	assert.Contains(t, text, `trinity_container_get_total{instance="UserService"} 2`)
+	assert.Contains(t, text, `trinity_container_release_total{instance="UserService"} 1`)
+	assert.Contains(t, text, `trinity_container_self_check_duration_seconds_count{instance="UserService",result="success"} 1`)
+	assert.Contains(t, text, `trinity_container_self_check_duration_seconds_count{instance="OrderService",result="failed"} 1`)
+}
@@ -0,0 +1,303 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	counterType   = "counter"
+	gaugeType     = "gauge"
+	histogramType = "histogram"
+)
+
+var (
+	// DefaultBuckets the default buckets of the histogram in seconds, the same as the prometheus client
+	DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+)
+
+// vec the metric family partitioned by the label values
+type vec struct {
+	name       string
+	help       string
+	typ        string
+	labelNames []string
+	mu         sync.Mutex
+	children   map[string]*child
+}
+
+type child struct {
+	labelValues []string
+	value       float64
+	// histogram only
+	counts []uint64
+	count  uint64
+}
+
+func newVec(name, help, typ string, labelNames []string) vec {
+	return vec{
+		name:       name,
+		help:       help,
+		typ:        typ,
+		labelNames: labelNames,
+		children:   make(map[string]*child),
+	}
+}
+
+func (v *vec) Name() string {
+	return v.name
+}
+
+// child get or create the child by the label values, v.mu should be locked
+func (v *vec) child(labelValues []string) *child {
+	c, ok := v.lookup(labelValues)
+	if !ok {
+		c = &child{labelValues: append([]string(nil), labelValues...)}
+		v.children[v.key(labelValues)] = c
+	}
+	return c
+}
+
+// lookup get the child by the label values without creating it, v.mu should be locked
+func (v *vec) lookup(labelValues []string) (*child, bool) {
+	c, ok := v.children[v.key(labelValues)]
+	return c, ok
+}
+
+func (v *vec) key(labelValues []string) string {
+	if len(labelValues) != len(v.labelNames) {
+		panic(fmt.Sprintf("metrics: %v expected %v label values, actual: %v", v.name, len(v.labelNames), len(labelValues)))
+	}
+	return strings.Join(labelValues, "\xff")
+}
+
+// sortedChildren the children sorted by the label values, v.mu should be locked
+func (v *vec) sortedChildren() []*child {
+	keys := make([]string, 0, len(v.children))
+	for key := range v.children {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	children := make([]*child, 0, len(keys))
+	for _, key := range keys {
+		children = append(children, v.children[key])
+	}
+	return children
+}
+
+func (v *vec) writeHeader(w io.Writer) error {
+	_, err := fmt.Fprintf(w, "# HELP %v %v\n# TYPE %v %v\n", v.name, escapeHelp(v.help), v.name, v.typ)
+	return err
+}
+
+func (v *vec) writeValues(w io.Writer) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if err := v.writeHeader(w); err != nil {
+		return err
+	}
+	for _, c := range v.sortedChildren() {
+		if _, err := fmt.Fprintf(w, "%v%v %v\n", v.name, formatLabels(v.labelNames, c.labelValues), formatFloat(c.value)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Counter the value only goes up, e.g. the number of the requests
+type Counter struct {
+	vec
+}
+
+func NewCounter(name, help string, labelNames ...string) *Counter {
+	return &Counter{vec: newVec(name, help, counterType, labelNames)}
+}
+
+func (c *Counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add add the delta, panic if the delta is negative
+func (c *Counter) Add(delta float64, labelValues ...string) {
+	if delta < 0 {
+		panic(fmt.Sprintf("metrics: counter %v cannot decrease", c.name))
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.child(labelValues).value += delta
+}
+
+// Value the current value of the label values, 0 if never added
+func (c *Counter) Value(labelValues ...string) float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if child, ok := c.lookup(labelValues); ok {
+		return child.value
+	}
+	return 0
+}
+
+func (c *Counter) WriteText(w io.Writer) error {
+	return c.writeValues(w)
+}
+
+// Gauge the value goes up and down, e.g. the number of the in-flight requests
+type Gauge struct {
+	vec
+}
+
+func NewGauge(name, help string, labelNames ...string) *Gauge {
+	return &Gauge{vec: newVec(name, help, gaugeType, labelNames)}
+}
+
+func (g *Gauge) Inc(labelValues ...string) {
+	g.Add(1, labelValues...)
+}
+
+func (g *Gauge) Dec(labelValues ...string) {
+	g.Add(-1, labelValues...)
+}
+
+func (g *Gauge) Add(delta float64, labelValues ...string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.child(labelValues).value += delta
+}
+
+func (g *Gauge) Set(value float64, labelValues ...string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.child(labelValues).value = value
+}
+
+// Value the current value of the label values
+func (g *Gauge) Value(labelValues ...string) float64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if child, ok := g.lookup(labelValues); ok {
+		return child.value
+	}
+	return 0
+}
+
+func (g *Gauge) WriteText(w io.Writer) error {
+	return g.writeValues(w)
+}
+
+// Histogram count the observations in the buckets, e.g. the request latency
+type Histogram struct {
+	vec
+	buckets []float64
+}
+
+// NewHistogram new the histogram, the buckets are sorted, DefaultBuckets is used if no bucket passed
+func NewHistogram(name, help string, buckets []float64, labelNames ...string) *Histogram {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+	sorted := append([]float64(nil), buckets...)
+	sort.Float64s(sorted)
+	return &Histogram{
+		vec:     newVec(name, help, histogramType, labelNames),
+		buckets: sorted,
+	}
+}
+
+func (h *Histogram) Observe(value float64, labelValues ...string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	c := h.child(labelValues)
+	if c.counts == nil {
+		c.counts = make([]uint64, len(h.buckets))
+	}
+	for i, bound := range h.buckets {
+		if value <= bound {
+			c.counts[i]++
+		}
+	}
+	c.count++
+	c.value += value
+}
+
+// Count the number of the observations of the label values
+func (h *Histogram) Count(labelValues ...string) uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if child, ok := h.lookup(labelValues); ok {
+		return child.count
+	}
+	return 0
+}
+
+func (h *Histogram) WriteText(w io.Writer) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if err := h.writeHeader(w); err != nil {
+		return err
+	}
+	labelNames := append(append([]string(nil), h.labelNames...), "le")
+	for _, c := range h.sortedChildren() {
+		labelValues := append(append([]string(nil), c.labelValues...), "")
+		for i, bound := range h.buckets {
+			var count uint64
+			if c.counts != nil {
+				count = c.counts[i]
+			}
+			labelValues[len(labelValues)-1] = formatFloat(bound)
+			if _, err := fmt.Fprintf(w, "%v_bucket%v %v\n", h.name, formatLabels(labelNames, labelValues), count); err != nil {
+				return err
+			}
+		}
+		labelValues[len(labelValues)-1] = "+Inf"
+		labels := formatLabels(h.labelNames, c.labelValues)
+		if _, err := fmt.Fprintf(w, "%v_bucket%v %v\n%v_sum%v %v\n%v_count%v %v\n",
+			h.name, formatLabels(labelNames, labelValues), c.count,
+			h.name, labels, formatFloat(c.value),
+			h.name, labels, c.count,
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func formatLabels(names, values []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = name + `="` + escapeLabelValue(values[i]) + `"`
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	_helpReplacer       = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	_labelValueReplacer = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(s string) string {
+	return _helpReplacer.Replace(s)
+}
+
+func escapeLabelValue(s string) string {
+	return _labelValueReplacer.Replace(s)
+}
@@ -0,0 +1,75 @@
+package metrics
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCounter(t *testing.T) {
+	c := NewCounter("requests_total", "The number of the requests.", "method", "status")
+	c.Inc("GET", "200")
+	c.Add(2, "GET", "200")
+	c.Inc("POST", "500")
+	assert.Equal(t, float64(3), c.Value("GET", "200"))
+	assert.Panics(t, func() { c.Add(-1, "GET", "200") })
+	assert.Panics(t, func() { c.Inc("GET") })
+	// reading the value not export the series
+	assert.Equal(t, float64(0), c.Value("DELETE", "404"))
+
+	buf := &bytes.Buffer{}
+	assert.NoError(t, c.WriteText(buf))
+	assert.Equal(t, `# HELP requests_total The number of the requests.
+# TYPE requests_total counter
+requests_total{method="GET",status="200"} 3
+requests_total{method="POST",status="500"} 1
+`, buf.String())
+}
+
+func TestGauge(t *testing.T) {
+	g := NewGauge("in_flight", "The in-flight requests.")
+	g.Inc()
+	g.Inc()
+	g.Dec()
+	assert.Equal(t, float64(1), g.Value())
+	g.Set(5.5)
+
+	buf := &bytes.Buffer{}
+	assert.NoError(t, g.WriteText(buf))
+	assert.Equal(t, `# HELP in_flight The in-flight requests.
+# TYPE in_flight gauge
+in_flight 5.5
+`, buf.String())
+}
+
+func TestHistogram(t *testing.T) {
+	h := NewHistogram("latency_seconds", "The latency.", []float64{1, 0.1}, "route")
+	h.Observe(0.05, "/users")
+	h.Observe(0.5, "/users")
+	h.Observe(3, "/users")
+	assert.Equal(t, uint64(3), h.Count("/users"))
+	assert.Equal(t, uint64(0), h.Count("/orders"))
+
+	buf := &bytes.Buffer{}
+	assert.NoError(t, h.WriteText(buf))
+	assert.Equal(t, `# HELP latency_seconds The latency.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{route="/users",le="0.1"} 1
+latency_seconds_bucket{route="/users",le="1"} 2
+latency_seconds_bucket{route="/users",le="+Inf"} 3
+latency_seconds_sum{route="/users"} 3.55
+latency_seconds_count{route="/users"} 3
+`, buf.String())
+}
+
+func TestEscape(t *testing.T) {
+	c := NewCounter("escape_total", "line1\nline2 \\", "path")
+	c.Inc("a\"b\\c\nd")
+	buf := &bytes.Buffer{}
+	assert.NoError(t, c.WriteText(buf))
+	assert.Equal(t, `# HELP escape_total line1\nline2 \\
+# TYPE escape_total counter
+escape_total{path="a\"b\\c\nd"} 1
+`, buf.String())
+}
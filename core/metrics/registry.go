@@ -0,0 +1,120 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+)
+
+const (
+	// ContentType the content type of the prometheus text exposition format
+	ContentType = "text/plain; version=0.0.4; charset=utf-8"
+)
+
+var (
+	_defaultRegistry = NewRegistry()
+)
+
+// Collector the metric written in the prometheus text format
+type Collector interface {
+	Name() string
+	WriteText(w io.Writer) error
+}
+
+// Registry the collectors exposed together
+type Registry struct {
+	mu         sync.RWMutex
+	collectors map[string]Collector
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		collectors: make(map[string]Collector),
+	}
+}
+
+// DefaultRegistry the registry used if not specified
+func DefaultRegistry() *Registry {
+	return _defaultRegistry
+}
+
+// Register register the collector, the name should be unique in the registry
+func (r *Registry) Register(c Collector) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.collectors[c.Name()]; ok {
+		return fmt.Errorf("metrics: collector %v already registered", c.Name())
+	}
+	r.collectors[c.Name()] = c
+	return nil
+}
+
+// MustRegister register the collectors, panic if any registered
+func (r *Registry) MustRegister(cs ...Collector) {
+	for _, c := range cs {
+		if err := r.Register(c); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// GetOrRegister register the collector, or return the registered one with the same name
+// so that the collectors could be created again for the same registry, e.g. the app created more than once in the tests
+// panic if the registered one is not the same type
+func GetOrRegister[T Collector](r *Registry, c T) T {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	registered, ok := r.collectors[c.Name()]
+	if !ok {
+		r.collectors[c.Name()] = c
+		return c
+	}
+	existing, ok := registered.(T)
+	if !ok {
+		panic(fmt.Errorf("metrics: collector %v already registered as %T", c.Name(), registered))
+	}
+	return existing
+}
+
+// Unregister remove the collector by the name
+func (r *Registry) Unregister(name string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, ok := r.collectors[name]
+	delete(r.collectors, name)
+	return ok
+}
+
+// WriteText write all the collectors sorted by the name in the prometheus text format
+func (r *Registry) WriteText(w io.Writer) error {
+	r.mu.RLock()
+	names := make([]string, 0, len(r.collectors))
+	for name := range r.collectors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	collectors := make([]Collector, 0, len(names))
+	for _, name := range names {
+		collectors = append(collectors, r.collectors[name])
+	}
+	r.mu.RUnlock()
+
+	bw := bufio.NewWriter(w)
+	for _, c := range collectors {
+		if err := c.WriteText(bw); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// Handler the handler responding the metrics to the scraper
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", ContentType)
+		r.WriteText(w)
+	})
+}
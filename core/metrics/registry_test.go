@@ -0,0 +1,46 @@
+package metrics
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry()
+	b := NewCounter("b_total", "b")
+	a := NewGauge("a", "a")
+	r.MustRegister(b, a)
+	assert.Error(t, r.Register(NewCounter("b_total", "duplicated")))
+	assert.Panics(t, func() { r.MustRegister(NewGauge("a", "duplicated")) })
+	b.Inc()
+	a.Set(2)
+
+	server := httptest.NewServer(r.Handler())
+	defer server.Close()
+	resp, err := http.Get(server.URL)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, ContentType, resp.Header.Get("Content-Type"))
+	assert.Equal(t, `# HELP a a
+# TYPE a gauge
+a 2
+# HELP b_total b
+# TYPE b_total counter
+b_total 1
+`, string(body))
+
+	assert.True(t, r.Unregister("a"))
+	assert.False(t, r.Unregister("a"))
+}
+
+func TestGetOrRegister(t *testing.T) {
+	r := NewRegistry()
+	a := GetOrRegister(r, NewCounter("a_total", "a"))
+	assert.Same(t, a, GetOrRegister(r, NewCounter("a_total", "a")))
+	assert.Panics(t, func() { GetOrRegister(r, NewGauge("a_total", "a")) })
+}
@@ -0,0 +1,41 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock the source of the current time, replaced by the fake clock in the test
+type Clock interface {
+	Now() time.Time
+}
+
+// SystemClock the clock of time.Now
+type SystemClock struct{}
+
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+// FakeClock the clock moved manually, it is used in the test
+type FakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Advance move the clock forward by d
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
@@ -0,0 +1,182 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+)
+
+type Algorithm string
+
+const (
+	// TokenBucket the tokens are refilled at the rate of Requests per Period, up to the Burst
+	TokenBucket Algorithm = "token_bucket"
+	// SlidingWindow the requests in the last Period, weighted from the previous window and the current window
+	SlidingWindow Algorithm = "sliding_window"
+)
+
+// Limit the rate limit, e.g. 100 requests per minute
+type Limit struct {
+	Requests int
+	Period   time.Duration
+	// the max tokens of the token bucket, the requests allowed at once
+	// default: Requests
+	Burst int
+	// default: TokenBucket
+	Algorithm Algorithm
+}
+
+func (l Limit) String() string {
+	return fmt.Sprintf("%v/%v %v", l.Requests, l.Period, l.Algorithm)
+}
+
+// Result the result of the request checked by the limiter
+type Result struct {
+	Allowed bool
+	Limit   int
+	// the requests left
+	Remaining int
+	// the duration until the limit fully reset
+	ResetAfter time.Duration
+	// the duration to wait before retry, zero if allowed
+	RetryAfter time.Duration
+}
+
+// Config the config of the Limiter
+type Config struct {
+	Limit Limit
+	// default: NewMemoryStore
+	Store Store
+	// default: SystemClock
+	Clock Clock
+}
+
+// Limiter limit the rate of the requests by the key
+type Limiter struct {
+	limit Limit
+	store Store
+	clock Clock
+}
+
+func New(c Config) *Limiter {
+	if c.Limit.Requests <= 0 || c.Limit.Period <= 0 {
+		panic(fmt.Sprintf("ratelimit: invalid limit %v", c.Limit))
+	}
+	if c.Limit.Burst <= 0 {
+		c.Limit.Burst = c.Limit.Requests
+	}
+	if c.Limit.Algorithm == "" {
+		c.Limit.Algorithm = TokenBucket
+	}
+	if c.Clock == nil {
+		c.Clock = SystemClock{}
+	}
+	if c.Store == nil {
+		c.Store = NewMemoryStore(c.Clock)
+	}
+	return &Limiter{
+		limit: c.Limit,
+		store: c.Store,
+		clock: c.Clock,
+	}
+}
+
+func (l *Limiter) Limit() Limit {
+	return l.limit
+}
+
+// Allow take one request of the key
+func (l *Limiter) Allow(ctx context.Context, key string) (Result, error) {
+	now := l.clock.Now()
+	var (
+		res Result
+		ttl time.Duration
+		fn  func(state State, exist bool) State
+	)
+	switch l.limit.Algorithm {
+	case TokenBucket:
+		// the bucket is full again after the ttl, so the expired state is the same as the new one
+		ttl = time.Duration(float64(l.limit.Period) * float64(l.limit.Burst) / float64(l.limit.Requests))
+		fn = func(state State, exist bool) State {
+			state, res = l.takeToken(state, exist, now)
+			return state
+		}
+	case SlidingWindow:
+		ttl = 2 * l.limit.Period
+		fn = func(state State, exist bool) State {
+			state, res = l.slide(state, exist, now)
+			return state
+		}
+	default:
+		return Result{}, fmt.Errorf("ratelimit: algorithm %v not supported", l.limit.Algorithm)
+	}
+	if err := l.store.Update(ctx, key, ttl, fn); err != nil {
+		return Result{}, fmt.Errorf("ratelimit: store update error, err: %w", err)
+	}
+	return res, nil
+}
+
+func (l *Limiter) takeToken(state State, exist bool, now time.Time) (State, Result) {
+	capacity := float64(l.limit.Burst)
+	// tokens per second
+	rate := float64(l.limit.Requests) / l.limit.Period.Seconds()
+	tokens := capacity
+	if exist {
+		elapsed := now.Sub(state.Time).Seconds()
+		if elapsed < 0 {
+			elapsed = 0
+		}
+		tokens = math.Min(capacity, state.Count+elapsed*rate)
+	}
+	res := Result{Limit: l.limit.Burst}
+	if tokens >= 1 {
+		tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = seconds((1 - tokens) / rate)
+	}
+	res.Remaining = int(math.Floor(tokens))
+	res.ResetAfter = seconds((capacity - tokens) / rate)
+	return State{Count: tokens, Time: now}, res
+}
+
+func (l *Limiter) slide(state State, exist bool, now time.Time) (State, Result) {
+	period := l.limit.Period
+	windowStart := now.Truncate(period)
+	if !exist || !state.Time.Equal(windowStart) {
+		prev := 0.0
+		if exist && state.Time.Equal(windowStart.Add(-period)) {
+			prev = state.Count
+		}
+		state = State{PrevCount: prev, Time: windowStart}
+	}
+	elapsed := now.Sub(windowStart)
+	limit := float64(l.limit.Requests)
+	weight := 1 - float64(elapsed)/float64(period)
+	estimated := state.PrevCount*weight + state.Count
+	res := Result{
+		Limit:      l.limit.Requests,
+		ResetAfter: windowStart.Add(2 * period).Sub(now),
+	}
+	if estimated+1 <= limit {
+		state.Count++
+		res.Allowed = true
+		res.Remaining = int(math.Floor(limit - estimated - 1))
+		return state, res
+	}
+	// wait until the weight of the previous window decreased enough, or the next window
+	windowEnd := windowStart.Add(period).Sub(now)
+	res.RetryAfter = windowEnd
+	if state.Count+1 <= limit && state.PrevCount > 0 {
+		wait := time.Duration(float64(period)*(1-(limit-state.Count-1)/state.PrevCount)) - elapsed
+		if wait < windowEnd {
+			res.RetryAfter = wait
+		}
+	}
+	return state, res
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(math.Ceil(s * float64(time.Second)))
+}
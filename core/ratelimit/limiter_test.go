@@ -0,0 +1,103 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	testStart = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+)
+
+func allowN(t *testing.T, l *Limiter, key string, n int) []Result {
+	res := make([]Result, 0, n)
+	for i := 0; i < n; i++ {
+		r, err := l.Allow(context.Background(), key)
+		assert.NoError(t, err)
+		res = append(res, r)
+	}
+	return res
+}
+
+func TestLimiter_TokenBucket(t *testing.T) {
+	clock := NewFakeClock(testStart)
+	l := New(Config{Limit: Limit{Requests: 10, Period: 10 * time.Second, Burst: 3}, Clock: clock})
+	assert.Equal(t, TokenBucket, l.Limit().Algorithm)
+
+	res := allowN(t, l, "a", 4)
+	assert.Equal(t, []bool{true, true, true, false}, []bool{res[0].Allowed, res[1].Allowed, res[2].Allowed, res[3].Allowed})
+	assert.Equal(t, 3, res[0].Limit)
+	assert.Equal(t, 2, res[0].Remaining)
+	assert.Equal(t, 0, res[2].Remaining)
+	assert.Equal(t, 3*time.Second, res[2].ResetAfter)
+	assert.Equal(t, time.Second, res[3].RetryAfter)
+
+	// the other key is not limited
+	assert.True(t, allowN(t, l, "b", 1)[0].Allowed)
+
+	clock.Advance(time.Second)
+	res = allowN(t, l, "a", 2)
+	assert.True(t, res[0].Allowed)
+	assert.False(t, res[1].Allowed)
+
+	clock.Advance(time.Hour)
+	res = allowN(t, l, "a", 1)
+	assert.True(t, res[0].Allowed)
+	assert.Equal(t, 2, res[0].Remaining)
+}
+
+func TestLimiter_SlidingWindow(t *testing.T) {
+	clock := NewFakeClock(testStart)
+	l := New(Config{Limit: Limit{Requests: 4, Period: time.Minute, Algorithm: SlidingWindow}, Clock: clock})
+
+	res := allowN(t, l, "a", 5)
+	for i := 0; i < 4; i++ {
+		assert.True(t, res[i].Allowed)
+		assert.Equal(t, 3-i, res[i].Remaining)
+	}
+	assert.False(t, res[4].Allowed)
+	assert.Equal(t, time.Minute, res[4].RetryAfter)
+
+	// 4 * 0.75 = 3 weighted from the previous window
+	clock.Advance(75 * time.Second)
+	res = allowN(t, l, "a", 2)
+	assert.True(t, res[0].Allowed)
+	assert.Equal(t, 0, res[0].Remaining)
+	assert.False(t, res[1].Allowed)
+	// 4 * (1 - t/60) + 1 + 1 <= 4 => t >= 30s
+	assert.Equal(t, 15*time.Second, res[1].RetryAfter)
+
+	clock.Advance(15 * time.Second)
+	assert.True(t, allowN(t, l, "a", 1)[0].Allowed)
+
+	// the previous window is dropped after 2 periods
+	clock.Advance(2 * time.Minute)
+	res = allowN(t, l, "a", 1)
+	assert.True(t, res[0].Allowed)
+	assert.Equal(t, 3, res[0].Remaining)
+}
+
+func TestLimiter_InvalidLimit(t *testing.T) {
+	assert.Panics(t, func() { New(Config{Limit: Limit{Requests: 0, Period: time.Second}}) })
+	assert.Panics(t, func() { New(Config{Limit: Limit{Requests: 1}}) })
+
+	l := New(Config{Limit: Limit{Requests: 1, Period: time.Second, Algorithm: "unknown"}})
+	_, err := l.Allow(context.Background(), "a")
+	assert.Error(t, err)
+}
+
+type errStore struct{}
+
+func (errStore) Update(ctx context.Context, key string, ttl time.Duration, fn func(state State, exist bool) State) error {
+	return errors.New("connection refused")
+}
+
+func TestLimiter_StoreError(t *testing.T) {
+	l := New(Config{Limit: Limit{Requests: 1, Period: time.Second}, Store: errStore{}})
+	_, err := l.Allow(context.Background(), "a")
+	assert.ErrorContains(t, err, "connection refused")
+}
@@ -0,0 +1,90 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	// the interval to remove the expired keys of the MemoryStore
+	memorySweepInterval = time.Minute
+)
+
+// State the state of the key kept by the store
+type State struct {
+	// token bucket: the tokens left, sliding window: the count of the current window
+	Count float64
+	// sliding window: the count of the previous window
+	PrevCount float64
+	// token bucket: the last refilled time, sliding window: the start of the current window
+	Time time.Time
+}
+
+// Store keep the states of the keys, e.g. in the memory or in the redis
+type Store interface {
+	// Update update the state of the key atomically, exist is false if the key not exist or expired
+	// the new state returned by fn expires after the ttl
+	Update(ctx context.Context, key string, ttl time.Duration, fn func(state State, exist bool) State) error
+}
+
+type memoryEntry struct {
+	state    State
+	expireAt time.Time
+}
+
+// MemoryStore the store in the memory of the process, the limit is not shared between the instances of the app
+type MemoryStore struct {
+	mu        sync.Mutex
+	entries   map[string]memoryEntry
+	clock     Clock
+	lastSweep time.Time
+}
+
+// NewMemoryStore new the memory store, the clock decides the expiration of the keys
+// default: SystemClock
+func NewMemoryStore(clock ...Clock) *MemoryStore {
+	var c Clock = SystemClock{}
+	if len(clock) > 0 && clock[0] != nil {
+		c = clock[0]
+	}
+	return &MemoryStore{
+		entries:   make(map[string]memoryEntry),
+		clock:     c,
+		lastSweep: c.Now(),
+	}
+}
+
+func (s *MemoryStore) Update(ctx context.Context, key string, ttl time.Duration, fn func(state State, exist bool) State) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.clock.Now()
+	if now.Sub(s.lastSweep) >= memorySweepInterval {
+		s.sweep(now)
+	}
+	entry, exist := s.entries[key]
+	if exist && !now.Before(entry.expireAt) {
+		exist = false
+	}
+	s.entries[key] = memoryEntry{
+		state:    fn(entry.state, exist),
+		expireAt: now.Add(ttl),
+	}
+	return nil
+}
+
+// Len the number of the keys, including the expired ones not swept
+func (s *MemoryStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.entries)
+}
+
+func (s *MemoryStore) sweep(now time.Time) {
+	for key, entry := range s.entries {
+		if !now.Before(entry.expireAt) {
+			delete(s.entries, key)
+		}
+	}
+	s.lastSweep = now
+}
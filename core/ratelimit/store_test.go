@@ -0,0 +1,41 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryStore(t *testing.T) {
+	clock := NewFakeClock(testStart)
+	s := NewMemoryStore(clock)
+	update := func(key string, ttl time.Duration) (State, bool) {
+		var (
+			got   State
+			found bool
+		)
+		assert.NoError(t, s.Update(context.Background(), key, ttl, func(state State, exist bool) State {
+			got, found = state, exist
+			state.Count++
+			return state
+		}))
+		return got, found
+	}
+	_, exist := update("a", time.Second)
+	assert.False(t, exist)
+	state, exist := update("a", time.Second)
+	assert.True(t, exist)
+	assert.Equal(t, float64(1), state.Count)
+
+	clock.Advance(time.Second)
+	_, exist = update("a", 2*time.Minute)
+	assert.False(t, exist)
+
+	update("b", time.Second)
+	assert.Equal(t, 2, s.Len())
+	clock.Advance(time.Minute)
+	update("c", time.Second)
+	assert.Equal(t, 2, s.Len())
+}
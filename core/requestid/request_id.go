@@ -0,0 +1,48 @@
+package requestid
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/codeduckcloud/trinity-go/core/utils"
+)
+
+type requestIDContextKey string
+
+const (
+	RequestIDContext requestIDContextKey = "REQUEST_ID_CONTEXT"
+	// HeaderName the header carrying the request id, it is read from the request, echoed in the response and forwarded to the outbound call
+	HeaderName = "X-Request-ID"
+	// MaxLength the incoming request id longer than it is replaced by the generated one
+	MaxLength = 128
+)
+
+// New generate the request id by the snowflake id
+func New() string {
+	return strconv.FormatInt(utils.GetSnowflakeID(), 10)
+}
+
+// WithCtx set the request id in the ctx
+func WithCtx(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, RequestIDContext, id)
+}
+
+// FromCtx get the request id, return empty if not exist
+func FromCtx(ctx context.Context) string {
+	id, _ := ctx.Value(RequestIDContext).(string)
+	return id
+}
+
+// IsValid check whether the incoming request id is safe to be logged and echoed
+// only the printable ascii is allowed
+func IsValid(id string) bool {
+	if id == "" || len(id) > MaxLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7E {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,31 @@
+package requestid
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestIDContext(t *testing.T) {
+	assert.Equal(t, "", FromCtx(context.Background()))
+	ctx := WithCtx(context.Background(), "req-1")
+	assert.Equal(t, "req-1", FromCtx(ctx))
+}
+
+func TestNew(t *testing.T) {
+	id1 := New()
+	id2 := New()
+	assert.NotEmpty(t, id1)
+	assert.NotEqual(t, id1, id2)
+	assert.True(t, IsValid(id1))
+}
+
+func TestIsValid(t *testing.T) {
+	assert.True(t, IsValid("0f8fad5b-d9cb-469f-a165-70867728950e"))
+	assert.False(t, IsValid(""))
+	assert.False(t, IsValid("req 1"))
+	assert.False(t, IsValid("req\n1"))
+	assert.False(t, IsValid(strings.Repeat("a", MaxLength+1)))
+}
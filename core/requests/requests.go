@@ -16,6 +16,12 @@ import (
 	"io/ioutil"
 	"net/http"
 	"strings"
+
+	"github.com/codeduckcloud/trinity-go/core/requestid"
+	"github.com/codeduckcloud/trinity-go/core/tracex"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type Interceptor func(r *http.Request) error
@@ -33,6 +39,8 @@ func NewRequest() Requests {
 // will return the err when the response code is not >=200 or <= 300
 // will decode the response to dest even it return error
 func (r *HttpRequest) Call(ctx context.Context, method string, url string, header http.Header, body interface{}, dest interface{}, requestInterceptors ...Interceptor) error {
+	// the header of the caller is not modified, so that it could be reused
+	header = header.Clone()
 	if header == nil {
 		header = make(http.Header)
 	}
@@ -44,8 +52,21 @@ func (r *HttpRequest) Call(ctx context.Context, method string, url string, heade
 	if err != nil {
 		return fmt.Errorf("new request error, err: %v", err)
 	}
+	ctx, span := tracex.Start(ctx, method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.HTTPRequestMethodKey.String(method),
+			semconv.URLFull(req.URL.Redacted()),
+			semconv.ServerAddress(req.URL.Hostname()),
+		),
+	)
+	defer span.End()
 	req.Close = true
 	req.Header = header
+	if id := requestid.FromCtx(ctx); id != "" && req.Header.Get(requestid.HeaderName) == "" {
+		req.Header.Set(requestid.HeaderName, id)
+	}
+	tracex.Inject(ctx, req.Header)
 	for _, interceptor := range requestInterceptors {
 		if err := interceptor(req); err != nil {
 			return fmt.Errorf("new request interceptor error, err: %v", err)
@@ -54,9 +75,15 @@ func (r *HttpRequest) Call(ctx context.Context, method string, url string, heade
 	client := new(http.Client)
 	resp, err := client.Do(req)
 	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
 		return fmt.Errorf("do request error, err: %v", err)
 	}
 	defer resp.Body.Close()
+	span.SetAttributes(semconv.HTTPResponseStatusCode(resp.StatusCode))
+	if resp.StatusCode >= http.StatusBadRequest {
+		span.SetStatus(codes.Error, resp.Status)
+	}
 	bodyRes, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
 		return fmt.Errorf("read request body error, err: %v", err)
@@ -15,7 +15,10 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/codeduckcloud/trinity-go/core/requestid"
+	"github.com/codeduckcloud/trinity-go/core/tracex"
 	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/codes"
 )
 
 type test struct {
@@ -269,3 +272,58 @@ func TestHttpRequest_Call_ReadBodyError(t *testing.T) {
 	err := r.Call(context.Background(), http.MethodGet, server.URL, nil, nil, &dest)
 	assert.Error(t, err)
 }
+
+func TestHttpRequest_Call_ForwardRequestID(t *testing.T) {
+	var got []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.Header.Get(requestid.HeaderName))
+		w.Header().Set(HeaderMime, MimeJson)
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	r := NewRequest()
+	ctx := requestid.WithCtx(context.Background(), "req-1")
+	var dest test
+	assert.NoError(t, r.Call(ctx, http.MethodGet, server.URL, nil, nil, &dest))
+	header := http.Header{requestid.HeaderName: []string{"req-2"}}
+	assert.NoError(t, r.Call(ctx, http.MethodGet, server.URL, header, nil, &dest))
+	assert.NoError(t, r.Call(context.Background(), http.MethodGet, server.URL, nil, nil, &dest))
+	assert.Equal(t, []string{"req-1", "req-2", ""}, got)
+
+	// the shared header is not modified by the call
+	got = nil
+	shared := make(http.Header)
+	assert.NoError(t, r.Call(requestid.WithCtx(context.Background(), "A"), http.MethodGet, server.URL, shared, nil, &dest))
+	assert.NoError(t, r.Call(requestid.WithCtx(context.Background(), "B"), http.MethodGet, server.URL, shared, nil, &dest))
+	assert.Equal(t, []string{"A", "B"}, got)
+	assert.Empty(t, shared)
+}
+
+func TestHttpRequest_Call_PropagateTrace(t *testing.T) {
+	tp, exporter := tracex.NewInMemoryProvider()
+	tracex.SetTracerProvider(tp)
+	defer tracex.SetTracerProvider(nil)
+
+	var traceparent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+		w.Header().Set(HeaderMime, MimeJson)
+		w.WriteHeader(http.StatusBadGateway)
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	ctx, parent := tp.Tracer("test").Start(context.Background(), "parent")
+	var dest test
+	assert.Error(t, NewRequest().Call(ctx, http.MethodGet, server.URL, nil, nil, &dest))
+	parent.End()
+
+	spans := exporter.GetSpans()
+	assert.Len(t, spans, 2)
+	client := spans[0]
+	assert.Equal(t, http.MethodGet, client.Name)
+	assert.Equal(t, parent.SpanContext().SpanID(), client.Parent.SpanID())
+	assert.Equal(t, codes.Error, client.Status.Code)
+	assert.Equal(t, "00-"+client.SpanContext.TraceID().String()+"-"+client.SpanContext.SpanID().String()+"-01", traceparent)
+}
@@ -0,0 +1,22 @@
+package tracex
+
+import (
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// NewInMemoryProvider new the tracer provider exporting the ended spans to the memory synchronously
+// it is used to verify the spans in the test
+//
+//	tp, exporter := tracex.NewInMemoryProvider()
+//	tracex.SetTracerProvider(tp)
+//	...
+//	spans := exporter.GetSpans()
+func NewInMemoryProvider() (*sdktrace.TracerProvider, *tracetest.InMemoryExporter) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+		sdktrace.WithSyncer(exporter),
+	)
+	return tp, exporter
+}
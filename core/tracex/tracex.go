@@ -0,0 +1,68 @@
+package tracex
+
+import (
+	"context"
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// TracerName the instrumentation name of the spans created by trinity
+	TracerName = "github.com/codeduckcloud/trinity-go"
+)
+
+var (
+	// nil means the otel global tracer provider
+	_tracerProvider trace.TracerProvider
+	_propagator     propagation.TextMapPropagator = propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	)
+)
+
+// SetTracerProvider set the tracer provider of the spans created by trinity
+// default: otel.GetTracerProvider, which is no-op unless otel.SetTracerProvider called
+func SetTracerProvider(tp trace.TracerProvider) {
+	_tracerProvider = tp
+}
+
+// SetPropagator set the propagator of the incoming and the outbound request
+// default: W3C traceparent and baggage
+func SetPropagator(p propagation.TextMapPropagator) {
+	_propagator = p
+}
+
+// Tracer the tracer of trinity
+func Tracer() trace.Tracer {
+	if _tracerProvider != nil {
+		return _tracerProvider.Tracer(TracerName)
+	}
+	return otel.GetTracerProvider().Tracer(TracerName)
+}
+
+// Start start the span with the tracer of trinity
+func Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, name, opts...)
+}
+
+// Extract extract the span context propagated in the header, e.g. traceparent
+func Extract(ctx context.Context, header http.Header) context.Context {
+	return _propagator.Extract(ctx, propagation.HeaderCarrier(header))
+}
+
+// Inject inject the span context of the ctx into the header
+func Inject(ctx context.Context, header http.Header) {
+	_propagator.Inject(ctx, propagation.HeaderCarrier(header))
+}
+
+// TraceID the trace id of the span in the ctx, return empty if no valid span
+func TraceID(ctx context.Context) string {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.HasTraceID() {
+		return ""
+	}
+	return sc.TraceID().String()
+}
@@ -0,0 +1,54 @@
+package tracex
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func withInMemoryProvider(t *testing.T) {
+	tp, _ := NewInMemoryProvider()
+	original := _tracerProvider
+	SetTracerProvider(tp)
+	t.Cleanup(func() {
+		SetTracerProvider(original)
+	})
+}
+
+func TestTraceID(t *testing.T) {
+	withInMemoryProvider(t)
+	assert.Equal(t, "", TraceID(context.Background()))
+
+	ctx, span := Start(context.Background(), "op")
+	defer span.End()
+	assert.Len(t, TraceID(ctx), 32)
+	assert.Equal(t, span.SpanContext().TraceID().String(), TraceID(ctx))
+}
+
+func TestInjectExtract(t *testing.T) {
+	withInMemoryProvider(t)
+	ctx, span := Start(context.Background(), "client")
+	defer span.End()
+
+	header := make(http.Header)
+	Inject(ctx, header)
+	assert.Contains(t, header.Get("traceparent"), span.SpanContext().TraceID().String())
+
+	extracted := Extract(context.Background(), header)
+	sc := trace.SpanContextFromContext(extracted)
+	assert.True(t, sc.IsRemote())
+	assert.Equal(t, span.SpanContext().TraceID(), sc.TraceID())
+	assert.Equal(t, span.SpanContext().SpanID(), sc.SpanID())
+}
+
+func TestNewInMemoryProvider(t *testing.T) {
+	tp, exporter := NewInMemoryProvider()
+	_, span := tp.Tracer(TracerName).Start(context.Background(), "op")
+	span.End()
+	spans := exporter.GetSpans()
+	assert.Len(t, spans, 1)
+	assert.Equal(t, "op", spans[0].Name)
+}
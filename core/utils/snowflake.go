@@ -1,10 +1,21 @@
 package utils
 
 import (
+	"sync"
+
 	"github.com/bwmarrin/snowflake"
 )
 
+var (
+	_snowflakeNode     *snowflake.Node
+	_snowflakeNodeOnce sync.Once
+)
+
+// GetSnowflakeID generate the unique id
+// the node is shared, so the ids generated in the same millisecond are different
 func GetSnowflakeID() int64 {
-	node, _ := snowflake.NewNode(1)
-	return node.Generate().Int64()
+	_snowflakeNodeOnce.Do(func() {
+		_snowflakeNode, _ = snowflake.NewNode(1)
+	})
+	return _snowflakeNode.Generate().Int64()
 }
@@ -12,3 +12,11 @@ func TestGetSnowflakeID(t *testing.T) {
 	assert.NotZero(t, id1)
 	assert.NotZero(t, id2)
 }
+
+func TestGetSnowflakeID_Unique(t *testing.T) {
+	ids := make(map[int64]struct{})
+	for i := 0; i < 1000; i++ {
+		ids[GetSnowflakeID()] = struct{}{}
+	}
+	assert.Len(t, ids, 1000)
+}
@@ -1,12 +1,58 @@
 package utils
 
 import (
+	"encoding"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"reflect"
 	"strconv"
+	"strings"
+	"sync"
+	"time"
 )
 
+const (
+	// the time format of unix timestamp in seconds
+	TimeFormatUnix = "unix"
+	// the time format of unix timestamp in milliseconds
+	TimeFormatUnixMilli = "unixmilli"
+)
+
+var (
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+	_stringConvertersMu sync.RWMutex
+	_stringConverters   = map[reflect.Type]StringConverterFunc{
+		reflect.TypeOf(time.Time{}):      timeConverter,
+		reflect.TypeOf(time.Duration(0)): durationConverter,
+	}
+)
+
+// StringConverterFunc convert the word to the value of the registered type
+// format is the `format` tag of the field, empty if not set
+type StringConverterFunc func(word string, format string) (interface{}, error)
+
+// RegisterStringConverter register the converter for the dest type
+// the registered converter has the highest priority
+// e.g.
+//
+//	utils.RegisterStringConverter(reflect.TypeOf(decimal.Decimal{}), func(word string, format string) (interface{}, error) {
+//		return decimal.NewFromString(word)
+//	})
+func RegisterStringConverter(t reflect.Type, fn StringConverterFunc) {
+	_stringConvertersMu.Lock()
+	defer _stringConvertersMu.Unlock()
+	_stringConverters[t] = fn
+}
+
+func getStringConverter(t reflect.Type) (StringConverterFunc, bool) {
+	_stringConvertersMu.RLock()
+	defer _stringConvertersMu.RUnlock()
+	fn, ok := _stringConverters[t]
+	return fn, ok
+}
+
 // StringConverter
 /*
  @word the word need to be converted
@@ -16,74 +62,122 @@ import (
  the type of destVal
 */
 func StringConverter(word string, destVal *reflect.Value) error {
+	return StringConverterWithFormat(word, "", destVal)
+}
+
+// StringConverterWithFormat
+/*
+ @word the word need to be converted
+ @format the format of the word, e.g. the layout of time.Time, unix, unixmilli
+ @destVal the target value need to convert to
+
+ the converter will be chosen by the order
+ 1. the converter registered by RegisterStringConverter
+ 2. encoding.TextUnmarshaler, e.g. net.IP, *big.Int, decimal
+ 3. [N]byte, e.g. uuid, will be decoded from hex string, `-` will be ignored
+ 4. the kind of destVal
+*/
+func StringConverterWithFormat(word string, format string, destVal *reflect.Value) error {
+	if fn, ok := getStringConverter(destVal.Type()); ok {
+		paramVal, err := fn(word, format)
+		if err != nil {
+			return err
+		}
+		val := reflect.ValueOf(paramVal)
+		if !val.IsValid() || !isConvertible(val.Type(), destVal.Type()) {
+			return fmt.Errorf("converter of %v returned %T, not convertible", destVal.Type(), paramVal)
+		}
+		destVal.Set(val.Convert(destVal.Type()))
+		return nil
+	}
+	if destVal.Kind() != reflect.Ptr && destVal.Kind() != reflect.Interface && reflect.PointerTo(destVal.Type()).Implements(textUnmarshalerType) {
+		targetVal := reflect.New(destVal.Type())
+		if err := targetVal.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(word)); err != nil {
+			return err
+		}
+		destVal.Set(targetVal.Elem())
+		return nil
+	}
 	switch destVal.Type().Kind() {
 	case reflect.Int64:
 		paramVal, err := strconv.ParseInt(word, 10, 64)
 		if err != nil {
 			return err
 		}
-		destVal.Set(reflect.ValueOf(paramVal))
+		destVal.SetInt(paramVal)
 	case reflect.Int32:
 		paramVal, err := strconv.ParseInt(word, 10, 32)
 		if err != nil {
 			return err
 		}
-		destVal.Set(reflect.ValueOf(int32(paramVal)))
+		destVal.SetInt(paramVal)
 	case reflect.Int16:
 		paramVal, err := strconv.ParseInt(word, 10, 16)
 		if err != nil {
 			return err
 		}
-		destVal.Set(reflect.ValueOf(int16(paramVal)))
+		destVal.SetInt(paramVal)
 	case reflect.Int:
 		paramVal, err := strconv.Atoi(word)
 		if err != nil {
 			return err
 		}
-		destVal.Set(reflect.ValueOf(paramVal))
+		destVal.SetInt(int64(paramVal))
 	case reflect.Uint:
 		paramVal, err := strconv.Atoi(word)
 		if err != nil {
 			return err
 		}
-		destVal.Set(reflect.ValueOf(uint(paramVal)))
+		destVal.SetUint(uint64(paramVal))
 	case reflect.Uint64:
 		paramVal, err := strconv.ParseUint(word, 10, 64)
 		if err != nil {
 			return err
 		}
-		destVal.Set(reflect.ValueOf(paramVal))
+		destVal.SetUint(paramVal)
 	case reflect.Uint32:
 		paramVal, err := strconv.ParseUint(word, 10, 32)
 		if err != nil {
 			return err
 		}
-		destVal.Set(reflect.ValueOf(uint32(paramVal)))
+		destVal.SetUint(paramVal)
 	case reflect.Uint16:
 		paramVal, err := strconv.ParseUint(word, 10, 16)
 		if err != nil {
 			return err
 		}
-		destVal.Set(reflect.ValueOf(uint16(paramVal)))
+		destVal.SetUint(paramVal)
 	case reflect.Float32:
 		paramVal, err := strconv.ParseFloat(word, 32)
 		if err != nil {
 			return err
 		}
-		destVal.Set(reflect.ValueOf(float32(paramVal)))
+		destVal.SetFloat(paramVal)
 	case reflect.Float64:
 		paramVal, err := strconv.ParseFloat(word, 64)
 		if err != nil {
 			return err
 		}
-		destVal.Set(reflect.ValueOf(paramVal))
+		destVal.SetFloat(paramVal)
 	case reflect.Bool:
 		paramVal, _ := strconv.ParseBool(word)
-		destVal.Set(reflect.ValueOf(paramVal))
+		destVal.SetBool(paramVal)
 	case reflect.String:
-		destVal.Set(reflect.ValueOf(word))
+		destVal.SetString(word)
 	case reflect.Interface:
 		destVal.Set(reflect.ValueOf(word))
+	case reflect.Array:
+		if destVal.Type().Elem().Kind() != reflect.Uint8 {
+			return fmt.Errorf("unsupported type")
+		}
+		paramVal, err := hex.DecodeString(strings.ReplaceAll(word, "-", ""))
+		if err != nil {
+			return err
+		}
+		if len(paramVal) != destVal.Len() {
+			return fmt.Errorf("wrong length, expected: %v bytes, actual: %v bytes", destVal.Len(), len(paramVal))
+		}
+		reflect.Copy(*destVal, reflect.ValueOf(paramVal))
 	case reflect.Struct:
 		targetVal := reflect.New(destVal.Type()).Interface()
 		if err := json.Unmarshal([]byte(word), targetVal); err != nil {
@@ -93,7 +187,7 @@ func StringConverter(word string, destVal *reflect.Value) error {
 	case reflect.Ptr:
 		targetVal := reflect.New(destVal.Type().Elem())
 		dest := targetVal.Elem()
-		if err := StringConverter(word, &dest); err != nil {
+		if err := StringConverterWithFormat(word, format, &dest); err != nil {
 			return err
 		}
 		destVal.Set(targetVal)
@@ -102,3 +196,44 @@ func StringConverter(word string, destVal *reflect.Value) error {
 	}
 	return nil
 }
+
+// timeConverter convert the word to time.Time
+// format could be the time layout, unix or unixmilli, RFC3339 by default
+// isConvertible check the value returned by the converter could be converted to the dest type
+// the integer to string conversion is rejected, as it yields the rune instead of the digits
+func isConvertible(from reflect.Type, to reflect.Type) bool {
+	if to.Kind() == reflect.String {
+		switch from.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			return false
+		}
+	}
+	return from.ConvertibleTo(to)
+}
+
+func timeConverter(word string, format string) (interface{}, error) {
+	switch format {
+	case "":
+		return time.Parse(time.RFC3339Nano, word)
+	case TimeFormatUnix:
+		sec, err := strconv.ParseInt(word, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		return time.Unix(sec, 0), nil
+	case TimeFormatUnixMilli:
+		msec, err := strconv.ParseInt(word, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		return time.UnixMilli(msec), nil
+	default:
+		return time.Parse(format, word)
+	}
+}
+
+// durationConverter convert the word to time.Duration, e.g. 1h30m, 500ms
+func durationConverter(word string, format string) (interface{}, error) {
+	return time.ParseDuration(word)
+}
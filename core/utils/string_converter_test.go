@@ -1,8 +1,13 @@
 package utils
 
 import (
+	"fmt"
+	"math/big"
+	"net"
 	"reflect"
+	"strconv"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -226,3 +231,203 @@ func Test_StringConverter_Unsupported(t *testing.T) {
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "unsupported type")
 }
+
+type testCelsius float64
+
+func Test_StringConverter_ExtendedTypes(t *testing.T) {
+	type Test struct {
+		T1  time.Time
+		T2  time.Time
+		T3  *time.Time
+		D1  time.Duration
+		IP  net.IP
+		U   [16]byte
+		B   *big.Int
+		L   testLevel
+		C   testCelsius
+		PIP *net.IP
+	}
+	dest := &Test{}
+	{
+		val := getStructFieldValue(dest, 0)
+		assert.NoError(t, StringConverter("2021-10-02T01:05:20Z", &val))
+		assert.Equal(t, time.Date(2021, 10, 2, 1, 5, 20, 0, time.UTC), dest.T1)
+	}
+	{
+		val := getStructFieldValue(dest, 1)
+		assert.NoError(t, StringConverterWithFormat("2021-10-02", "2006-01-02", &val))
+		assert.Equal(t, time.Date(2021, 10, 2, 0, 0, 0, 0, time.UTC), dest.T2)
+	}
+	{
+		val := getStructFieldValue(dest, 2)
+		assert.NoError(t, StringConverterWithFormat("1633136720", TimeFormatUnix, &val))
+		assert.Equal(t, int64(1633136720), dest.T3.Unix())
+	}
+	{
+		val := getStructFieldValue(dest, 2)
+		assert.NoError(t, StringConverterWithFormat("1633136720123", TimeFormatUnixMilli, &val))
+		assert.Equal(t, int64(1633136720123), dest.T3.UnixMilli())
+	}
+	{
+		val := getStructFieldValue(dest, 3)
+		assert.NoError(t, StringConverter("1m30s", &val))
+		assert.Equal(t, 90*time.Second, dest.D1)
+	}
+	{
+		val := getStructFieldValue(dest, 4)
+		assert.NoError(t, StringConverter("10.0.0.1", &val))
+		assert.Equal(t, "10.0.0.1", dest.IP.String())
+	}
+	{
+		val := getStructFieldValue(dest, 5)
+		assert.NoError(t, StringConverter("123e4567-e89b-12d3-a456-426614174000", &val))
+		assert.Equal(t, byte(0x12), dest.U[0])
+		assert.Equal(t, byte(0x00), dest.U[15])
+	}
+	{
+		val := getStructFieldValue(dest, 6)
+		assert.NoError(t, StringConverter("123456789012345678901234567890", &val))
+		assert.Equal(t, "123456789012345678901234567890", dest.B.String())
+	}
+	{
+		val := getStructFieldValue(dest, 7)
+		assert.NoError(t, StringConverter("HIGH", &val))
+		assert.Equal(t, testLevel(2), dest.L)
+	}
+	{
+		val := getStructFieldValue(dest, 8)
+		assert.NoError(t, StringConverter("36.6", &val))
+		assert.Equal(t, testCelsius(36.6), dest.C)
+	}
+	{
+		val := getStructFieldValue(dest, 9)
+		assert.NoError(t, StringConverter("::1", &val))
+		assert.Equal(t, "::1", dest.PIP.String())
+	}
+}
+
+func Test_StringConverter_ExtendedTypesErr(t *testing.T) {
+	type Test struct {
+		T  time.Time
+		D  time.Duration
+		IP net.IP
+		U  [16]byte
+		A  [2]int
+		L  testLevel
+	}
+	dest := &Test{}
+	tests := []struct {
+		index  int
+		word   string
+		format string
+	}{
+		{index: 0, word: "2021-10-02"},
+		{index: 0, word: "abc", format: TimeFormatUnix},
+		{index: 0, word: "abc", format: TimeFormatUnixMilli},
+		{index: 1, word: "abc"},
+		{index: 2, word: "abc"},
+		{index: 3, word: "xyz"},
+		{index: 3, word: "1234"},
+		{index: 4, word: "12"},
+		{index: 5, word: "unknown"},
+	}
+	for _, tt := range tests {
+		val := getStructFieldValue(dest, tt.index)
+		assert.Error(t, StringConverterWithFormat(tt.word, tt.format, &val), tt.word)
+	}
+}
+
+type testMoney struct {
+	cents int64
+}
+
+func Test_RegisterStringConverter(t *testing.T) {
+	moneyType := reflect.TypeOf(testMoney{})
+	RegisterStringConverter(moneyType, func(word string, format string) (interface{}, error) {
+		f, err := strconv.ParseFloat(word, 64)
+		if err != nil {
+			return nil, err
+		}
+		return testMoney{cents: int64(f * 100)}, nil
+	})
+	defer func() {
+		_stringConvertersMu.Lock()
+		delete(_stringConverters, moneyType)
+		_stringConvertersMu.Unlock()
+	}()
+	type Test struct {
+		M  testMoney
+		PM *testMoney
+	}
+	dest := &Test{}
+	{
+		val := getStructFieldValue(dest, 0)
+		assert.NoError(t, StringConverter("12.34", &val))
+		assert.Equal(t, int64(1234), dest.M.cents)
+	}
+	{
+		val := getStructFieldValue(dest, 1)
+		assert.NoError(t, StringConverter("1", &val))
+		assert.Equal(t, int64(100), dest.PM.cents)
+	}
+	{
+		val := getStructFieldValue(dest, 0)
+		assert.Error(t, StringConverter("abc", &val))
+	}
+}
+
+func Test_RegisterStringConverter_WrongType(t *testing.T) {
+	moneyType := reflect.TypeOf(testMoney{})
+	var result interface{}
+	RegisterStringConverter(moneyType, func(word string, format string) (interface{}, error) {
+		return result, nil
+	})
+	defer func() {
+		_stringConvertersMu.Lock()
+		delete(_stringConverters, moneyType)
+		_stringConvertersMu.Unlock()
+	}()
+	type Test struct {
+		M testMoney
+	}
+	dest := &Test{}
+	for _, result = range []interface{}{nil, "12.34"} {
+		val := getStructFieldValue(dest, 0)
+		assert.Error(t, StringConverter("12.34", &val))
+	}
+}
+
+type testCode string
+
+func Test_RegisterStringConverter_IntToString(t *testing.T) {
+	codeType := reflect.TypeOf(testCode(""))
+	RegisterStringConverter(codeType, func(word string, format string) (interface{}, error) {
+		return strconv.Atoi(word)
+	})
+	defer func() {
+		_stringConvertersMu.Lock()
+		delete(_stringConverters, codeType)
+		_stringConvertersMu.Unlock()
+	}()
+	type Test struct {
+		C testCode
+	}
+	dest := &Test{}
+	val := getStructFieldValue(dest, 0)
+	assert.Error(t, StringConverter("65", &val))
+	assert.Equal(t, testCode(""), dest.C)
+}
+
+type testLevel int
+
+func (l *testLevel) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "LOW":
+		*l = 1
+	case "HIGH":
+		*l = 2
+	default:
+		return fmt.Errorf("unknown level %v", string(text))
+	}
+	return nil
+}
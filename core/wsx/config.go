@@ -0,0 +1,92 @@
+package wsx
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultReadLimit int64 = 1 << 20
+	// the hard limit of the message read, the larger ReadLimit is limited to it
+	MaxReadLimit        int64 = 64 << 20
+	DefaultPingInterval       = 30 * time.Second
+	DefaultWriteTimeout       = 10 * time.Second
+	DefaultCloseTimeout       = 3 * time.Second
+)
+
+var (
+	_defaultConfig = Config{}
+)
+
+// Config the websocket connection config
+type Config struct {
+	// the max size of the message read, the connection is closed with 1009 if exceeded
+	// default: DefaultReadLimit, MaxReadLimit if < 0
+	ReadLimit int64
+	// the interval to send the ping, the ping is disabled if < 0
+	// default: DefaultPingInterval
+	PingInterval time.Duration
+	// the connection is closed if nothing received from the peer during the wait
+	// default: 2 * PingInterval, no wait if the ping disabled
+	PongWait time.Duration
+	// default: DefaultWriteTimeout
+	WriteTimeout time.Duration
+	// the max duration to wait for the close frame of the peer after Close sent the close frame
+	// default: DefaultCloseTimeout
+	CloseTimeout time.Duration
+	// the subprotocols supported by the server, in the order of preference
+	Subprotocols []string
+	// CheckOrigin check the Origin header of the upgrade request
+	// default: the origin host must be the same as the request host
+	CheckOrigin func(r *http.Request) bool
+}
+
+// SetConfig set the default config of the websocket connection
+func SetConfig(c Config) {
+	_defaultConfig = c
+}
+
+// GetConfig get the default config of the websocket connection
+func GetConfig() Config {
+	return _defaultConfig
+}
+
+func (c Config) withDefault() Config {
+	if c.ReadLimit == 0 {
+		c.ReadLimit = DefaultReadLimit
+	}
+	if c.ReadLimit < 0 || c.ReadLimit > MaxReadLimit {
+		c.ReadLimit = MaxReadLimit
+	}
+	if c.PingInterval == 0 {
+		c.PingInterval = DefaultPingInterval
+	}
+	if c.PongWait == 0 && c.PingInterval > 0 {
+		c.PongWait = 2 * c.PingInterval
+	}
+	if c.WriteTimeout == 0 {
+		c.WriteTimeout = DefaultWriteTimeout
+	}
+	if c.CloseTimeout <= 0 {
+		c.CloseTimeout = DefaultCloseTimeout
+	}
+	if c.CheckOrigin == nil {
+		c.CheckOrigin = SameOrigin
+	}
+	return c
+}
+
+// SameOrigin allow the request without Origin header or the Origin host is the same as the request host
+func SameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(u.Host, r.Host)
+}
@@ -0,0 +1,473 @@
+package wsx
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+	"unicode/utf8"
+)
+
+type MessageType int
+
+const (
+	TextMessage   MessageType = 1
+	BinaryMessage MessageType = 2
+)
+
+const (
+	opContinuation = 0x0
+	opText         = 0x1
+	opBinary       = 0x2
+	opClose        = 0x8
+	opPing         = 0x9
+	opPong         = 0xA
+
+	finBit  = 0x80
+	maskBit = 0x80
+
+	maxControlPayload = 125
+
+	closeWriteTimeout = time.Second
+)
+
+// the close status codes, see RFC 6455 7.4.1
+const (
+	CloseNormalClosure       = 1000
+	CloseGoingAway           = 1001
+	CloseProtocolError       = 1002
+	CloseUnsupportedData     = 1003
+	CloseNoStatusReceived    = 1005
+	CloseAbnormalClosure     = 1006 // never sent, reported when the connection dropped without the close frame
+	CloseInvalidFramePayload = 1007
+	ClosePolicyViolation     = 1008
+	CloseMessageTooBig       = 1009
+	CloseInternalServerErr   = 1011
+)
+
+var (
+	ErrClosed       = errors.New("websocket: connection closed")
+	ErrReadLimit    = errors.New("websocket: read limit exceeded")
+	ErrBadHandshake = errors.New("websocket: bad handshake")
+	ErrInvalidUTF8  = errors.New("websocket: invalid utf-8 text message")
+)
+
+// CloseError the close frame received from the peer
+type CloseError struct {
+	Code   int
+	Reason string
+}
+
+func (e *CloseError) Error() string {
+	return fmt.Sprintf("websocket: close %v %v", e.Code, e.Reason)
+}
+
+// IsCloseError check whether the err is the CloseError with one of the codes
+// if no code passed, any CloseError matches
+func IsCloseError(err error, codes ...int) bool {
+	var closeErr *CloseError
+	if !errors.As(err, &closeErr) {
+		return false
+	}
+	if len(codes) == 0 {
+		return true
+	}
+	for _, code := range codes {
+		if closeErr.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+// Conn the websocket connection
+// one goroutine could read and another one could write concurrently
+// the ping and the pong are handled by the connection
+type Conn struct {
+	conn        net.Conn
+	br          *bufio.Reader
+	isServer    bool
+	config      Config
+	subprotocol string
+	readMu      sync.Mutex
+	writeMu     sync.Mutex
+	sendOnce    sync.Once
+	closing     atomic.Bool
+	closeOnce   sync.Once
+	closed      chan struct{}
+	registry    *Registry
+}
+
+func newConn(conn net.Conn, br *bufio.Reader, isServer bool, config Config) *Conn {
+	c := &Conn{
+		conn:     conn,
+		br:       br,
+		isServer: isServer,
+		config:   config.withDefault(),
+		closed:   make(chan struct{}),
+	}
+	c.extendReadDeadline()
+	if c.config.PingInterval > 0 {
+		go c.keepalive()
+	}
+	return c
+}
+
+// Subprotocol the negotiated subprotocol
+func (c *Conn) Subprotocol() string {
+	return c.subprotocol
+}
+
+func (c *Conn) RemoteAddr() net.Addr {
+	return c.conn.RemoteAddr()
+}
+
+// Done is closed when the connection closed
+func (c *Conn) Done() <-chan struct{} {
+	return c.closed
+}
+
+// ReadMessage read the next text or binary message
+// the CloseError is returned if the peer closed the connection
+// if the text message is not valid utf-8, the connection is closed with 1007
+func (c *Conn) ReadMessage() (MessageType, []byte, error) {
+	c.readMu.Lock()
+	defer c.readMu.Unlock()
+	if c.isClosed() {
+		return 0, nil, ErrClosed
+	}
+	var (
+		msgType MessageType
+		message []byte
+	)
+	for {
+		fin, opcode, payload, err := c.readFrame(int64(len(message)))
+		if err != nil {
+			return 0, nil, c.readErr(err)
+		}
+		c.extendReadDeadline()
+		switch opcode {
+		case opPing:
+			if err := c.writeFrame(opPong, payload); err != nil {
+				return 0, nil, c.readErr(err)
+			}
+			continue
+		case opPong:
+			continue
+		case opClose:
+			closeErr := parseClosePayload(payload)
+			code := closeErr.Code
+			if code == CloseNoStatusReceived {
+				code = CloseNormalClosure
+			}
+			c.close(code, "")
+			return 0, nil, closeErr
+		case opText, opBinary:
+			if msgType != 0 {
+				c.close(CloseProtocolError, "unexpected data frame")
+				return 0, nil, ErrClosed
+			}
+			msgType = MessageType(opcode)
+		case opContinuation:
+			if msgType == 0 {
+				c.close(CloseProtocolError, "unexpected continuation frame")
+				return 0, nil, ErrClosed
+			}
+		default:
+			c.close(CloseProtocolError, "unknown opcode")
+			return 0, nil, ErrClosed
+		}
+		message = append(message, payload...)
+		if fin {
+			if msgType == TextMessage && !utf8.Valid(message) {
+				c.close(CloseInvalidFramePayload, "invalid utf-8")
+				return 0, nil, ErrInvalidUTF8
+			}
+			return msgType, message, nil
+		}
+	}
+}
+
+// WriteMessage write the text or binary message
+func (c *Conn) WriteMessage(msgType MessageType, data []byte) error {
+	if msgType != TextMessage && msgType != BinaryMessage {
+		return fmt.Errorf("websocket: unsupported message type %v", msgType)
+	}
+	return c.writeFrame(byte(msgType), data)
+}
+
+// ReadJSON read the next message and decode it as json
+func (c *Conn) ReadJSON(v interface{}) error {
+	_, data, err := c.ReadMessage()
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// WriteJSON write the v as the json text message
+func (c *Conn) WriteJSON(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return c.WriteMessage(TextMessage, data)
+}
+
+// Ping send the ping frame
+func (c *Conn) Ping(data []byte) error {
+	return c.writeFrame(opPing, data)
+}
+
+// Close send the close frame with the code, and close the connection after the close frame of the peer received
+// the close frame of the peer is waited at most Config.CloseTimeout, see RFC 6455 7.1.1
+func (c *Conn) Close(code int, reason string) error {
+	if c.isClosed() {
+		return ErrClosed
+	}
+	c.sendClose(code, reason)
+	c.waitPeerClose()
+	c.teardown()
+	return nil
+}
+
+// close send the close frame and close the connection without waiting for the peer, see RFC 6455 7.1.7
+func (c *Conn) close(code int, reason string) {
+	c.sendClose(code, reason)
+	c.teardown()
+}
+
+// sendClose send the close frame once, no more frames are written after it
+func (c *Conn) sendClose(code int, reason string) {
+	c.sendOnce.Do(func() {
+		payload := make([]byte, 2, 2+len(reason))
+		binary.BigEndian.PutUint16(payload, uint16(code))
+		payload = append(payload, reason...)
+		if len(payload) > maxControlPayload {
+			payload = payload[:maxControlPayload]
+		}
+		c.writeMu.Lock()
+		defer c.writeMu.Unlock()
+		c.closing.Store(true)
+		if c.isClosed() {
+			return
+		}
+		c.conn.SetWriteDeadline(time.Now().Add(closeWriteTimeout))
+		c.conn.Write(c.encodeFrame(opClose, payload))
+	})
+}
+
+// waitPeerClose wait for the close frame of the peer until the close timeout
+// the frames are read and discarded here if no goroutine is reading the connection
+func (c *Conn) waitPeerClose() {
+	c.conn.SetReadDeadline(time.Now().Add(c.config.CloseTimeout))
+	if c.readMu.TryLock() {
+		defer c.readMu.Unlock()
+		for !c.isClosed() {
+			_, opcode, _, err := c.readFrame(0)
+			if err != nil || opcode == opClose {
+				return
+			}
+		}
+		return
+	}
+	// the reading goroutine closes the connection when the close frame received
+	timer := time.NewTimer(c.config.CloseTimeout)
+	defer timer.Stop()
+	select {
+	case <-c.closed:
+	case <-timer.C:
+	}
+}
+
+// teardown close the underlying connection
+func (c *Conn) teardown() {
+	c.closeOnce.Do(func() {
+		close(c.closed)
+		c.conn.Close()
+		if c.registry != nil {
+			c.registry.remove(c)
+		}
+	})
+}
+
+func (c *Conn) isClosed() bool {
+	select {
+	case <-c.closed:
+		return true
+	default:
+		return false
+	}
+}
+
+func (c *Conn) readErr(err error) error {
+	if errors.Is(err, ErrReadLimit) {
+		c.close(CloseMessageTooBig, "message too big")
+		return err
+	}
+	if c.isClosed() {
+		return ErrClosed
+	}
+	var netErr net.Error
+	switch {
+	case errors.As(err, &netErr) && netErr.Timeout():
+		// nothing received from the peer during the wait
+		c.close(CloseGoingAway, "")
+		return err
+	case errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, net.ErrClosed) || errors.Is(err, syscall.ECONNRESET):
+		// the peer dropped the connection without the close frame
+		c.teardown()
+		return &CloseError{Code: CloseAbnormalClosure}
+	}
+	c.close(CloseProtocolError, "")
+	return err
+}
+
+func (c *Conn) keepalive() {
+	ticker := time.NewTicker(c.config.PingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.closed:
+			return
+		case <-ticker.C:
+			if err := c.Ping(nil); err != nil {
+				c.close(CloseGoingAway, "")
+				return
+			}
+		}
+	}
+}
+
+func (c *Conn) extendReadDeadline() {
+	if c.config.PongWait > 0 && !c.closing.Load() {
+		c.conn.SetReadDeadline(time.Now().Add(c.config.PongWait))
+	}
+}
+
+// readFrame read the frame, read is the length of the message already read
+func (c *Conn) readFrame(read int64) (bool, byte, []byte, error) {
+	var header [2]byte
+	if _, err := io.ReadFull(c.br, header[:]); err != nil {
+		return false, 0, nil, err
+	}
+	fin := header[0]&finBit != 0
+	opcode := header[0] & 0x0F
+	if header[0]&0x70 != 0 {
+		return false, 0, nil, fmt.Errorf("websocket: reserved bits set")
+	}
+	masked := header[1]&maskBit != 0
+	if masked != c.isServer {
+		return false, 0, nil, fmt.Errorf("websocket: invalid mask bit, masked: %v", masked)
+	}
+	length := int64(header[1] & 0x7F)
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(c.br, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = int64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(c.br, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = int64(binary.BigEndian.Uint64(ext[:]))
+		if length < 0 {
+			return false, 0, nil, fmt.Errorf("websocket: invalid payload length")
+		}
+	}
+	if opcode >= opClose {
+		if !fin || length > maxControlPayload {
+			return false, 0, nil, fmt.Errorf("websocket: invalid control frame")
+		}
+	} else if length > c.config.ReadLimit-read {
+		return false, 0, nil, ErrReadLimit
+	}
+	var maskKey [4]byte
+	if masked {
+		if _, err := io.ReadFull(c.br, maskKey[:]); err != nil {
+			return false, 0, nil, err
+		}
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(c.br, payload); err != nil {
+		return false, 0, nil, err
+	}
+	if masked {
+		maskBytes(maskKey, payload)
+	}
+	return fin, opcode, payload, nil
+}
+
+func (c *Conn) writeFrame(opcode byte, payload []byte) error {
+	if opcode >= opClose && len(payload) > maxControlPayload {
+		return fmt.Errorf("websocket: control frame payload too long")
+	}
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if c.closing.Load() || c.isClosed() {
+		return ErrClosed
+	}
+	if c.config.WriteTimeout > 0 {
+		c.conn.SetWriteDeadline(time.Now().Add(c.config.WriteTimeout))
+	}
+	_, err := c.conn.Write(c.encodeFrame(opcode, payload))
+	return err
+}
+
+// encodeFrame encode the single frame, the client frame is masked
+func (c *Conn) encodeFrame(opcode byte, payload []byte) []byte {
+	frame := make([]byte, 0, 14+len(payload))
+	frame = append(frame, finBit|opcode)
+	var maskFlag byte
+	if !c.isServer {
+		maskFlag = maskBit
+	}
+	length := len(payload)
+	switch {
+	case length <= 125:
+		frame = append(frame, maskFlag|byte(length))
+	case length <= 0xFFFF:
+		frame = append(frame, maskFlag|126, 0, 0)
+		binary.BigEndian.PutUint16(frame[len(frame)-2:], uint16(length))
+	default:
+		frame = append(frame, maskFlag|127, 0, 0, 0, 0, 0, 0, 0, 0)
+		binary.BigEndian.PutUint64(frame[len(frame)-8:], uint64(length))
+	}
+	if c.isServer {
+		return append(frame, payload...)
+	}
+	var maskKey [4]byte
+	rand.Read(maskKey[:])
+	frame = append(frame, maskKey[:]...)
+	start := len(frame)
+	frame = append(frame, payload...)
+	maskBytes(maskKey, frame[start:])
+	return frame
+}
+
+func maskBytes(key [4]byte, b []byte) {
+	for i := range b {
+		b[i] ^= key[i%4]
+	}
+}
+
+func parseClosePayload(payload []byte) *CloseError {
+	if len(payload) < 2 {
+		return &CloseError{Code: CloseNoStatusReceived}
+	}
+	return &CloseError{
+		Code:   int(binary.BigEndian.Uint16(payload)),
+		Reason: string(payload[2:]),
+	}
+}
@@ -0,0 +1,202 @@
+package wsx
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestServer(t *testing.T, config Config, handler func(conn *Conn)) string {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := Upgrade(w, r, config)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		handler(conn)
+	}))
+	t.Cleanup(server.Close)
+	return "ws" + strings.TrimPrefix(server.URL, "http")
+}
+
+func echo(conn *Conn) {
+	for {
+		msgType, msg, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		if err := conn.WriteMessage(msgType, msg); err != nil {
+			return
+		}
+	}
+}
+
+func TestConn_Echo(t *testing.T) {
+	url := newTestServer(t, Config{}, echo)
+	conn, _, err := Dial(context.Background(), url, nil)
+	assert.Nil(t, err)
+	defer conn.Close(CloseNormalClosure, "")
+
+	assert.Nil(t, conn.WriteMessage(TextMessage, []byte("hello")))
+	msgType, msg, err := conn.ReadMessage()
+	assert.Nil(t, err)
+	assert.Equal(t, TextMessage, msgType)
+	assert.Equal(t, "hello", string(msg))
+
+	large := []byte(strings.Repeat("a", 70000))
+	assert.Nil(t, conn.WriteMessage(BinaryMessage, large))
+	msgType, msg, err = conn.ReadMessage()
+	assert.Nil(t, err)
+	assert.Equal(t, BinaryMessage, msgType)
+	assert.Equal(t, large, msg)
+
+	assert.Nil(t, conn.WriteJSON(map[string]string{"name": "trinity"}))
+	var res map[string]string
+	assert.Nil(t, conn.ReadJSON(&res))
+	assert.Equal(t, "trinity", res["name"])
+}
+
+func TestConn_CloseByServer(t *testing.T) {
+	url := newTestServer(t, Config{}, func(conn *Conn) {
+		conn.Close(CloseGoingAway, "bye")
+	})
+	conn, _, err := Dial(context.Background(), url, nil)
+	assert.Nil(t, err)
+	_, _, err = conn.ReadMessage()
+	assert.True(t, IsCloseError(err, CloseGoingAway))
+	var closeErr *CloseError
+	assert.True(t, errors.As(err, &closeErr))
+	assert.Equal(t, "bye", closeErr.Reason)
+	assert.Equal(t, ErrClosed, conn.WriteMessage(TextMessage, []byte("x")))
+}
+
+func TestConn_ReadLimit(t *testing.T) {
+	result := make(chan error, 1)
+	url := newTestServer(t, Config{ReadLimit: 8}, func(conn *Conn) {
+		_, _, err := conn.ReadMessage()
+		result <- err
+	})
+	conn, _, err := Dial(context.Background(), url, nil)
+	assert.Nil(t, err)
+	assert.Nil(t, conn.WriteMessage(TextMessage, []byte("more than 8 bytes")))
+	assert.ErrorIs(t, <-result, ErrReadLimit)
+	_, _, err = conn.ReadMessage()
+	assert.True(t, IsCloseError(err, CloseMessageTooBig))
+}
+
+func TestConn_MaxReadLimit(t *testing.T) {
+	result := make(chan error, 1)
+	url := newTestServer(t, Config{ReadLimit: -1}, func(conn *Conn) {
+		_, _, err := conn.ReadMessage()
+		result <- err
+	})
+	conn, _, err := Dial(context.Background(), url, nil)
+	assert.Nil(t, err)
+	// the frame header declares the 1TB binary payload
+	header := []byte{finBit | opBinary, maskBit | 127, 0, 0, 0, 0, 0, 0, 0, 0, 1, 2, 3, 4}
+	binary.BigEndian.PutUint64(header[2:10], 1<<40)
+	_, err = conn.conn.Write(header)
+	assert.Nil(t, err)
+	assert.ErrorIs(t, <-result, ErrReadLimit)
+	_, _, err = conn.ReadMessage()
+	assert.True(t, IsCloseError(err, CloseMessageTooBig))
+}
+
+func TestConn_InvalidUTF8(t *testing.T) {
+	result := make(chan error, 1)
+	url := newTestServer(t, Config{}, func(conn *Conn) {
+		_, _, err := conn.ReadMessage()
+		result <- err
+	})
+	conn, _, err := Dial(context.Background(), url, nil)
+	assert.Nil(t, err)
+	assert.Nil(t, conn.WriteMessage(TextMessage, []byte{0xff, 0xfe}))
+	assert.ErrorIs(t, <-result, ErrInvalidUTF8)
+	_, _, err = conn.ReadMessage()
+	assert.True(t, IsCloseError(err, CloseInvalidFramePayload))
+}
+
+func TestConn_PingPong(t *testing.T) {
+	url := newTestServer(t, Config{PingInterval: 10 * time.Millisecond}, echo)
+	// the client reply the pong in ReadMessage, so the connection is kept alive longer than the pong wait
+	conn, _, err := Dial(context.Background(), url, nil)
+	assert.Nil(t, err)
+	defer conn.Close(CloseNormalClosure, "")
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		conn.WriteMessage(TextMessage, []byte("alive"))
+	}()
+	_, msg, err := conn.ReadMessage()
+	assert.Nil(t, err)
+	assert.Equal(t, "alive", string(msg))
+}
+
+func TestConn_PongWaitTimeout(t *testing.T) {
+	result := make(chan error, 1)
+	url := newTestServer(t, Config{PingInterval: 10 * time.Millisecond, PongWait: 20 * time.Millisecond}, func(conn *Conn) {
+		_, _, err := conn.ReadMessage()
+		result <- err
+	})
+	// the client never read, so the ping is never replied
+	conn, _, err := Dial(context.Background(), url, nil)
+	assert.Nil(t, err)
+	defer conn.Close(CloseNormalClosure, "")
+	select {
+	case err := <-result:
+		assert.NotNil(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("connection not closed after the pong wait")
+	}
+}
+
+func TestConn_CloseWaitPeer(t *testing.T) {
+	elapsed := make(chan time.Duration, 1)
+	url := newTestServer(t, Config{CloseTimeout: 50 * time.Millisecond}, func(conn *Conn) {
+		start := time.Now()
+		conn.Close(CloseNormalClosure, "")
+		elapsed <- time.Since(start)
+	})
+	// the client not reply the close frame, so the server wait until the close timeout
+	conn, _, err := Dial(context.Background(), url, nil)
+	assert.Nil(t, err)
+	assert.GreaterOrEqual(t, <-elapsed, 50*time.Millisecond)
+	_, _, err = conn.ReadMessage()
+	assert.True(t, IsCloseError(err, CloseNormalClosure))
+}
+
+func TestConn_ReadTimeout(t *testing.T) {
+	url := newTestServer(t, Config{PingInterval: -1, PongWait: 20 * time.Millisecond}, func(conn *Conn) {
+		conn.ReadMessage()
+	})
+	conn, _, err := Dial(context.Background(), url, nil)
+	assert.Nil(t, err)
+	_, _, err = conn.ReadMessage()
+	assert.True(t, IsCloseError(err, CloseGoingAway))
+}
+
+func TestConn_Dropped(t *testing.T) {
+	result := make(chan error, 1)
+	url := newTestServer(t, Config{}, func(conn *Conn) {
+		_, _, err := conn.ReadMessage()
+		result <- err
+	})
+	conn, _, err := Dial(context.Background(), url, nil)
+	assert.Nil(t, err)
+	conn.conn.Close()
+	assert.True(t, IsCloseError(<-result, CloseAbnormalClosure))
+}
+
+func TestIsCloseError(t *testing.T) {
+	err := &CloseError{Code: CloseNormalClosure}
+	assert.True(t, IsCloseError(err))
+	assert.True(t, IsCloseError(err, CloseGoingAway, CloseNormalClosure))
+	assert.False(t, IsCloseError(err, CloseGoingAway))
+	assert.False(t, IsCloseError(errors.New("x")))
+}
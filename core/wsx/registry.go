@@ -0,0 +1,73 @@
+package wsx
+
+import (
+	"sync"
+)
+
+var (
+	_defaultRegistry = newRegistry()
+)
+
+// Registry track the upgraded connections, so that they could be closed on the server shutdown
+// the hijacked connections are not tracked by http.Server.Shutdown
+type Registry struct {
+	mu    sync.Mutex
+	conns map[*Conn]struct{}
+}
+
+func newRegistry() *Registry {
+	return &Registry{
+		conns: make(map[*Conn]struct{}),
+	}
+}
+
+func (r *Registry) add(c *Conn) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c.registry = r
+	r.conns[c] = struct{}{}
+}
+
+func (r *Registry) remove(c *Conn) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.conns, c)
+}
+
+// Len the number of the open connections
+func (r *Registry) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.conns)
+}
+
+// CloseAll send the close frame to all the open connections and close them
+// the connections are closed concurrently, so it returns in one Config.CloseTimeout
+func (r *Registry) CloseAll(code int, reason string) {
+	r.mu.Lock()
+	conns := make([]*Conn, 0, len(r.conns))
+	for c := range r.conns {
+		conns = append(conns, c)
+	}
+	r.mu.Unlock()
+	var wg sync.WaitGroup
+	for _, c := range conns {
+		wg.Add(1)
+		go func(c *Conn) {
+			defer wg.Done()
+			c.Close(code, reason)
+		}(c)
+	}
+	wg.Wait()
+}
+
+// Connections the number of the open connections upgraded by Upgrade
+func Connections() int {
+	return _defaultRegistry.Len()
+}
+
+// CloseAll close all the connections upgraded by Upgrade
+// it is called on the server shutdown with CloseGoingAway
+func CloseAll(code int, reason string) {
+	_defaultRegistry.CloseAll(code, reason)
+}
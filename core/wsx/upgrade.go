@@ -0,0 +1,184 @@
+package wsx
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	acceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+	version    = "13"
+)
+
+// IsWebSocketUpgrade check whether the request is the websocket upgrade request
+func IsWebSocketUpgrade(r *http.Request) bool {
+	return headerContainsToken(r.Header, "Connection", "upgrade") &&
+		headerContainsToken(r.Header, "Upgrade", "websocket")
+}
+
+// Upgrade upgrade the http connection to the websocket connection
+// the config is merged with the default config, see SetConfig
+// if the handshake failed, the error is returned and nothing written to w
+func Upgrade(w http.ResponseWriter, r *http.Request, config ...Config) (*Conn, error) {
+	c := _defaultConfig
+	if len(config) > 0 {
+		c = config[0]
+	}
+	c = c.withDefault()
+	if r.Method != http.MethodGet {
+		return nil, fmt.Errorf("%w: method %v not allowed", ErrBadHandshake, r.Method)
+	}
+	if !IsWebSocketUpgrade(r) {
+		return nil, fmt.Errorf("%w: not websocket upgrade request", ErrBadHandshake)
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != version {
+		return nil, fmt.Errorf("%w: unsupported version %v", ErrBadHandshake, r.Header.Get("Sec-WebSocket-Version"))
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if key == "" {
+		return nil, fmt.Errorf("%w: Sec-WebSocket-Key missing", ErrBadHandshake)
+	}
+	if !c.CheckOrigin(r) {
+		return nil, fmt.Errorf("%w: origin %v not allowed", ErrBadHandshake, r.Header.Get("Origin"))
+	}
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		return nil, fmt.Errorf("%w: response writer %T not support hijack", ErrBadHandshake, w)
+	}
+	subprotocol := selectSubprotocol(r, c.Subprotocols)
+	netConn, brw, err := hijacker.Hijack()
+	if err != nil {
+		return nil, fmt.Errorf("%w: hijack error, err: %v", ErrBadHandshake, err)
+	}
+	response := "HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + acceptKey(key) + "\r\n"
+	if subprotocol != "" {
+		response += "Sec-WebSocket-Protocol: " + subprotocol + "\r\n"
+	}
+	response += "\r\n"
+	if _, err := netConn.Write([]byte(response)); err != nil {
+		netConn.Close()
+		return nil, err
+	}
+	conn := newConn(netConn, brw.Reader, true, c)
+	conn.subprotocol = subprotocol
+	_defaultRegistry.add(conn)
+	return conn, nil
+}
+
+// Dial connect to the websocket server, the url scheme should be ws or wss
+// it is mainly used by the test with httptest.Server
+func Dial(ctx context.Context, rawURL string, header http.Header, config ...Config) (*Conn, *http.Response, error) {
+	c := Config{PingInterval: -1}
+	if len(config) > 0 {
+		c = config[0]
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, nil, err
+	}
+	switch u.Scheme {
+	case "ws":
+		u.Scheme = "http"
+	case "wss":
+		u.Scheme = "https"
+	default:
+		return nil, nil, fmt.Errorf("websocket: unsupported scheme %v", u.Scheme)
+	}
+	host := u.Host
+	if u.Port() == "" {
+		if u.Scheme == "https" {
+			host = net.JoinHostPort(u.Hostname(), "443")
+		} else {
+			host = net.JoinHostPort(u.Hostname(), "80")
+		}
+	}
+	dialer := &net.Dialer{}
+	netConn, err := dialer.DialContext(ctx, "tcp", host)
+	if err != nil {
+		return nil, nil, err
+	}
+	if u.Scheme == "https" {
+		tlsConn := tls.Client(netConn, &tls.Config{ServerName: u.Hostname()})
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			netConn.Close()
+			return nil, nil, err
+		}
+		netConn = tlsConn
+	}
+	nonce := make([]byte, 16)
+	rand.Read(nonce)
+	key := base64.StdEncoding.EncodeToString(nonce)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		netConn.Close()
+		return nil, nil, err
+	}
+	for k, values := range header {
+		req.Header[k] = values
+	}
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Sec-WebSocket-Key", key)
+	req.Header.Set("Sec-WebSocket-Version", version)
+	if err := req.Write(netConn); err != nil {
+		netConn.Close()
+		return nil, nil, err
+	}
+	br := bufio.NewReader(netConn)
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		netConn.Close()
+		return nil, nil, err
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols || resp.Header.Get("Sec-WebSocket-Accept") != acceptKey(key) {
+		netConn.Close()
+		return nil, resp, fmt.Errorf("%w: status %v", ErrBadHandshake, resp.StatusCode)
+	}
+	conn := newConn(netConn, br, false, c)
+	conn.subprotocol = resp.Header.Get("Sec-WebSocket-Protocol")
+	return conn, resp, nil
+}
+
+func acceptKey(key string) string {
+	h := sha1.New()
+	h.Write([]byte(key + acceptGUID))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+func selectSubprotocol(r *http.Request, supported []string) string {
+	requested := make(map[string]bool)
+	for _, value := range r.Header.Values("Sec-WebSocket-Protocol") {
+		for _, protocol := range strings.Split(value, ",") {
+			requested[strings.TrimSpace(protocol)] = true
+		}
+	}
+	for _, protocol := range supported {
+		if requested[protocol] {
+			return protocol
+		}
+	}
+	return ""
+}
+
+func headerContainsToken(header http.Header, name, token string) bool {
+	for _, value := range header.Values(name) {
+		for _, t := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(t), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
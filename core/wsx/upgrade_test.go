@@ -0,0 +1,82 @@
+package wsx
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpgrade_BadHandshake(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		header map[string]string
+	}{
+		{name: "method", method: http.MethodPost, header: map[string]string{"Connection": "Upgrade", "Upgrade": "websocket", "Sec-WebSocket-Version": "13", "Sec-WebSocket-Key": "a"}},
+		{name: "not upgrade", method: http.MethodGet, header: map[string]string{}},
+		{name: "version", method: http.MethodGet, header: map[string]string{"Connection": "Upgrade", "Upgrade": "websocket", "Sec-WebSocket-Version": "8", "Sec-WebSocket-Key": "a"}},
+		{name: "key", method: http.MethodGet, header: map[string]string{"Connection": "Upgrade", "Upgrade": "websocket", "Sec-WebSocket-Version": "13"}},
+		{name: "origin", method: http.MethodGet, header: map[string]string{"Connection": "Upgrade", "Upgrade": "websocket", "Sec-WebSocket-Version": "13", "Sec-WebSocket-Key": "a", "Origin": "http://evil.com"}},
+		{name: "hijack", method: http.MethodGet, header: map[string]string{"Connection": "keep-alive, Upgrade", "Upgrade": "websocket", "Sec-WebSocket-Version": "13", "Sec-WebSocket-Key": "a"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, "http://example.com/ws", nil)
+			for k, v := range tt.header {
+				r.Header.Set(k, v)
+			}
+			_, err := Upgrade(httptest.NewRecorder(), r)
+			assert.ErrorIs(t, err, ErrBadHandshake)
+		})
+	}
+}
+
+func TestAcceptKey(t *testing.T) {
+	// the example in RFC 6455 1.3
+	assert.Equal(t, "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=", acceptKey("dGhlIHNhbXBsZSBub25jZQ=="))
+}
+
+func TestUpgrade_Subprotocol(t *testing.T) {
+	url := newTestServer(t, Config{Subprotocols: []string{"v2", "v1"}}, echo)
+	header := http.Header{}
+	header.Set("Sec-WebSocket-Protocol", "v1, v2")
+	conn, resp, err := Dial(context.Background(), url, header)
+	assert.Nil(t, err)
+	defer conn.Close(CloseNormalClosure, "")
+	assert.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode)
+	assert.Equal(t, "v2", conn.Subprotocol())
+}
+
+func TestDial_BadHandshake(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+	_, resp, err := Dial(context.Background(), "ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	assert.ErrorIs(t, err, ErrBadHandshake)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	_, _, err = Dial(context.Background(), server.URL, nil)
+	assert.NotNil(t, err)
+}
+
+func TestCloseAll(t *testing.T) {
+	url := newTestServer(t, Config{}, echo)
+	conn, _, err := Dial(context.Background(), url, nil)
+	assert.Nil(t, err)
+	assert.Eventually(t, func() bool { return Connections() == 1 }, time.Second, time.Millisecond)
+	// the client replies the close frame in ReadMessage, so CloseAll not wait for the close timeout
+	result := make(chan error, 1)
+	go func() {
+		_, _, err := conn.ReadMessage()
+		result <- err
+	}()
+	start := time.Now()
+	CloseAll(CloseGoingAway, "server shutdown")
+	assert.Less(t, time.Since(start), DefaultCloseTimeout)
+	assert.Equal(t, 0, Connections())
+	assert.True(t, IsCloseError(<-result, CloseGoingAway))
+}
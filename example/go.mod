@@ -4,6 +4,7 @@ go 1.19
 
 require (
 	github.com/codeduckcloud/trinity-go v1.0.0
+	github.com/go-chi/chi/v5 v5.0.7
 	github.com/sirupsen/logrus v1.9.0
 	github.com/spf13/cobra v1.5.0
 )
@@ -14,7 +15,6 @@ require (
 	github.com/bwmarrin/snowflake v0.3.0 // indirect
 	github.com/evalphobia/logrus_fluent v0.5.4 // indirect
 	github.com/fluent/fluent-logger-golang v1.9.0 // indirect
-	github.com/go-chi/chi/v5 v5.0.7 // indirect
 	github.com/go-playground/locales v0.14.0 // indirect
 	github.com/go-playground/universal-translator v0.18.0 // indirect
 	github.com/go-playground/validator/v10 v10.11.0 // indirect
@@ -2,20 +2,30 @@ package trinity
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"net/http"
 	"os"
 	"os/signal"
 	"path/filepath"
 	"reflect"
+	"sort"
 	"sync"
 	"syscall"
+	"time"
 
 	"github.com/codeduckcloud/trinity-go/core/container"
 	"github.com/codeduckcloud/trinity-go/core/httpx"
 	"github.com/codeduckcloud/trinity-go/core/logx"
+	"github.com/codeduckcloud/trinity-go/core/tracex"
+	"github.com/codeduckcloud/trinity-go/core/wsx"
 	"github.com/codeduckcloud/trinity-go/middleware"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// the max time to wait for the in-flight requests on shutdown
+	DefaultShutdownTimeout = 30 * time.Second
 )
 
 var (
@@ -38,11 +48,105 @@ type bootingController struct {
 }
 
 type RequestMap struct {
-	method   string
-	subPath  string
-	funcName string
-	handlers []func(http.Handler) http.Handler
-	isRaw    bool
+	method      string
+	subPath     string
+	funcName    string
+	handlers    []func(http.Handler) http.Handler
+	isRaw       bool
+	envelope    httpx.Envelope
+	requirement middleware.Requirement
+	cors        *middleware.CORSConfig
+	rateLimit   func(http.Handler) http.Handler
+	timeout     *time.Duration
+}
+
+// WithEnvelope set the response envelope of the route
+// e.g. httpx.NoEnvelope{} to respond the bare result, the params binding and the error handling are kept
+//
+//	trinity.NewRequestMapping("GET", "/health", "Health").WithEnvelope(httpx.NoEnvelope{})
+func (m RequestMap) WithEnvelope(env httpx.Envelope) RequestMap {
+	m.envelope = env
+	return m
+}
+
+// WithCORS set the CORS config of the route, override the Config.CORS of the app
+// the preflight request of the route is handled automatically
+//
+//	trinity.NewRequestMapping("POST", "/upload", "Upload").WithCORS(middleware.CORSConfig{AllowedOrigins: []string{"https://*.example.com"}})
+func (m RequestMap) WithCORS(c middleware.CORSConfig) RequestMap {
+	m.cors = &c
+	return m
+}
+
+// WithRateLimit limit the rate of the route, the limit is not shared with the other routes
+// use RateLimited to share the limit between the group of the routes
+//
+//	trinity.NewRequestMapping("POST", "/login", "Login").WithRateLimit(middleware.RateLimitConfig{
+//		Limit: ratelimit.Limit{Requests: 5, Period: time.Minute},
+//	})
+func (m RequestMap) WithRateLimit(c middleware.RateLimitConfig) RequestMap {
+	m.rateLimit = middleware.RateLimit(c)
+	return m
+}
+
+// RateLimited limit the rate of the group of the routes, the limit is shared by the routes
+//
+//	trinity.RegisterController("/api", "APIController", trinity.RateLimited(middleware.RateLimitConfig{
+//		Limit:   ratelimit.Limit{Requests: 1000, Period: time.Hour},
+//		KeyFunc: middleware.KeyByHeader(middleware.APIKeyHeader),
+//	}, requestMaps...)...)
+func RateLimited(c middleware.RateLimitConfig, requestMaps ...RequestMap) []RequestMap {
+	rateLimit := middleware.RateLimit(c)
+	res := make([]RequestMap, 0, len(requestMaps))
+	for _, requestMap := range requestMaps {
+		requestMap.rateLimit = rateLimit
+		res = append(res, requestMap)
+	}
+	return res
+}
+
+// WithTimeout set the timeout of the route, override the Config.RequestTimeout of the app
+// the timeout <= 0 disables the timeout of the route, see middleware.Timeout
+func (m RequestMap) WithTimeout(timeout time.Duration) RequestMap {
+	m.timeout = &timeout
+	return m
+}
+
+// WithRequirement declare the authorization requirement of the route, checked by middleware.Authorize
+// the claims should be set by the authentication middleware, e.g. middleware.Jwt
+//
+//	trinity.NewRequestMapping("DELETE", "/users/{id}", "DeleteUser", jwt).WithRoles("admin")
+func (m RequestMap) WithRequirement(requirement middleware.Requirement) RequestMap {
+	m.requirement = m.requirement.Merge(requirement)
+	return m
+}
+
+// WithRoles require one of the roles
+func (m RequestMap) WithRoles(roles ...string) RequestMap {
+	return m.WithRequirement(middleware.Requirement{Roles: roles})
+}
+
+// WithScopes require all of the scopes
+func (m RequestMap) WithScopes(scopes ...string) RequestMap {
+	return m.WithRequirement(middleware.Requirement{Scopes: scopes})
+}
+
+// WithPermissions require all of the permissions
+func (m RequestMap) WithPermissions(permissions ...string) RequestMap {
+	return m.WithRequirement(middleware.Requirement{Permissions: permissions})
+}
+
+// Secured declare the requirement for the group of the routes
+//
+//	trinity.RegisterController("/admin", "AdminController", trinity.Secured(middleware.Requirement{Roles: []string{"admin"}},
+//		trinity.NewRequestMapping("GET", "/users", "ListUsers", jwt),
+//	)...)
+func Secured(requirement middleware.Requirement, requestMaps ...RequestMap) []RequestMap {
+	res := make([]RequestMap, 0, len(requestMaps))
+	for _, requestMap := range requestMaps {
+		res = append(res, requestMap.WithRequirement(requirement))
+	}
+	return res
 }
 
 type bootingInstance struct {
@@ -100,6 +204,29 @@ func NewRawRequestMapping(method string, path string, funcName string, handlers
 	}
 }
 
+// NewWebSocketMapping register the websocket endpoint
+// the controller method receive the *wsx.Conn, the path/query params and the injected dependencies as the normal handler
+// the connection is upgraded after all the params parsed, and closed after the method returned
+//
+//	func (c *ChatController) Join(conn *wsx.Conn, args *JoinRequest) error {
+//		for {
+//			_, msg, err := conn.ReadMessage()
+//			if err != nil {
+//				return nil
+//			}
+//			c.room.Broadcast(args.RoomID, msg)
+//		}
+//	}
+func NewWebSocketMapping(path string, funcName string, handlers ...func(http.Handler) http.Handler) RequestMap {
+	return RequestMap{
+		method:   http.MethodGet,
+		subPath:  path,
+		funcName: funcName,
+		handlers: handlers,
+		isRaw:    false,
+	}
+}
+
 func (t *trinity) initInstance(ctx context.Context) {
 	switch t.container.GetInstanceType() {
 	case container.MultiInstance:
@@ -124,18 +251,136 @@ func (t *trinity) initInstance(ctx context.Context) {
 
 func (t *trinity) diRouter(ctx context.Context) {
 	t.mux.Use(logx.SessionLogger(ctx))
-	t.mux.Use(middleware.Recovery())
+	if t.config.Tracing {
+		t.mux.Use(middleware.Tracing())
+	}
+	t.mux.Use(middleware.RequestID())
+	if !t.config.DisableAccessLog {
+		t.mux.Use(middleware.AccessLog(t.config.AccessLog))
+	}
+	if t.config.Metrics {
+		t.mux.Use(middleware.Metrics(middleware.MetricsConfig{Registry: t.config.MetricsRegistry}))
+	}
+	t.mux.Use(middleware.Recovery(t.config.PanicHooks...))
+	if t.config.Metrics {
+		t.mux.Get(t.config.MetricsPath, t.config.MetricsRegistry.Handler().ServeHTTP)
+		logx.FromCtx(ctx).Infof("router   register handler: %-6s %-30s => metrics", http.MethodGet, t.config.MetricsPath)
+	}
 	t.routerSelfCheck(ctx)
 	// register router
+	preflights := make(map[string]map[string]middleware.CORSConfig)
+	optionsHandlers := make(map[string]http.HandlerFunc)
 	for _, controller := range _bootingControllers {
 		for _, requestMapping := range controller.requestMaps {
 			urlPath := filepath.Join(controller.rootPath, requestMapping.subPath)
 			h := http.HandlerFunc(DIHandler(t.container, controller.instanceName, requestMapping.funcName, requestMapping.isRaw))
+			if !requestMapping.requirement.IsEmpty() {
+				h = middleware.Authorize(requestMapping.requirement)(h).ServeHTTP
+			}
+			if requestMapping.rateLimit != nil {
+				h = requestMapping.rateLimit(h).ServeHTTP
+			}
+			if timeout := t.requestTimeout(requestMapping); timeout > 0 && !t.isLongLived(ctx, controller.instanceName, requestMapping.funcName) {
+				h = middleware.Timeout(timeout)(h).ServeHTTP
+			}
+			if requestMapping.envelope != nil {
+				h = httpx.UseEnvelope(requestMapping.envelope)(h).ServeHTTP
+			}
 			for i := len(requestMapping.handlers) - 1; i >= 0; i-- {
 				h = requestMapping.handlers[i](h).ServeHTTP
 			}
-			t.mux.MethodFunc(requestMapping.method, urlPath, h)
-			logx.FromCtx(ctx).Infof("router   register handler: %-6s %-30s => %v.%v ", requestMapping.method, urlPath, controller.instanceName, requestMapping.funcName)
+			if corsConfig := t.corsConfig(requestMapping); corsConfig != nil {
+				h = middleware.CORS(*corsConfig)(h).ServeHTTP
+				if requestMapping.method != http.MethodOptions {
+					if preflights[urlPath] == nil {
+						preflights[urlPath] = make(map[string]middleware.CORSConfig)
+					}
+					preflights[urlPath][requestMapping.method] = *corsConfig
+				}
+			}
+			if requestMapping.method == http.MethodOptions {
+				// registered with the preflight of the path, see registerOptions
+				optionsHandlers[urlPath] = h
+			} else {
+				t.mux.MethodFunc(requestMapping.method, urlPath, h)
+			}
+			t.routes = append(t.routes, Route{
+				Method:      requestMapping.method,
+				Path:        urlPath,
+				Controller:  controller.instanceName,
+				FuncName:    requestMapping.funcName,
+				Raw:         requestMapping.isRaw,
+				Requirement: requestMapping.requirement,
+			})
+			logx.FromCtx(ctx).Infof("router   register handler: %-6s %-30s => %v.%v %v", requestMapping.method, urlPath, controller.instanceName, requestMapping.funcName, requestMapping.requirement)
+		}
+	}
+	t.registerOptions(ctx, preflights, optionsHandlers)
+}
+
+// requestTimeout the timeout of the route, 0 if the timeout not enabled
+func (t *trinity) requestTimeout(requestMapping RequestMap) time.Duration {
+	if requestMapping.timeout != nil {
+		return *requestMapping.timeout
+	}
+	return t.config.RequestTimeout
+}
+
+// isLongLived check whether the controller method is the websocket or the event stream handler, see httpx.IsLongLived
+func (t *trinity) isLongLived(ctx context.Context, instanceName container.InstanceName, funcName string) bool {
+	injectMap := injectMapPool.Get().(map[container.InstanceName]interface{})
+	instance := t.container.GetInstance(ctx, instanceName, injectMap)
+	defer func() {
+		for k, v := range injectMap {
+			t.container.Release(ctx, k, v)
+			delete(injectMap, k)
+		}
+		injectMapPool.Put(injectMap)
+	}()
+	method, ok := reflect.TypeOf(instance).MethodByName(funcName)
+	return ok && httpx.IsLongLived(method.Type)
+}
+
+// corsConfig the CORS config of the route, nil if CORS not enabled
+func (t *trinity) corsConfig(requestMapping RequestMap) *middleware.CORSConfig {
+	if requestMapping.cors != nil {
+		return requestMapping.cors
+	}
+	return t.config.CORS
+}
+
+// registerOptions register the OPTIONS handler of the paths
+// the preflight request is handled by the CORS config of the method requested, even if the OPTIONS route registered explicitly
+// the other OPTIONS request is passed to the OPTIONS route, or responded with the Allow header if not registered
+func (t *trinity) registerOptions(ctx context.Context, preflights map[string]map[string]middleware.CORSConfig, optionsHandlers map[string]http.HandlerFunc) {
+	paths := make([]string, 0, len(preflights)+len(optionsHandlers))
+	for urlPath := range preflights {
+		paths = append(paths, urlPath)
+	}
+	for urlPath := range optionsHandlers {
+		if _, ok := preflights[urlPath]; !ok {
+			paths = append(paths, urlPath)
+		}
+	}
+	sort.Strings(paths)
+	for _, urlPath := range paths {
+		h, ok := optionsHandlers[urlPath]
+		configs, hasPreflight := preflights[urlPath]
+		switch {
+		case !hasPreflight:
+			t.mux.Options(urlPath, h)
+		case !ok:
+			t.mux.Options(urlPath, middleware.CORSPreflight(configs).ServeHTTP)
+			logx.FromCtx(ctx).Infof("router   register handler: %-6s %-30s => cors preflight", http.MethodOptions, urlPath)
+		default:
+			preflight := middleware.CORSPreflight(configs)
+			t.mux.Options(urlPath, func(w http.ResponseWriter, r *http.Request) {
+				if middleware.IsPreflight(r) {
+					preflight.ServeHTTP(w, r)
+					return
+				}
+				h(w, r)
+			})
 		}
 	}
 }
@@ -166,9 +411,17 @@ func (t *trinity) routerSelfCheck(ctx context.Context) {
 // multi instance di handler
 func DIHandler(c *container.Container, instanceName container.InstanceName, funcName string, isRaw bool) func(w http.ResponseWriter, r *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
-		r = r.WithContext(context.WithValue(r.Context(), httpx.HttpxContext, httpx.NewContext(r, 0)))
+		ctx, span := tracex.Start(r.Context(), fmt.Sprintf("%v.%v", instanceName, funcName),
+			trace.WithAttributes(attribute.String("trinity.instance", string(instanceName)), attribute.String("trinity.func", funcName)),
+		)
+		defer span.End()
+		r = r.WithContext(context.WithValue(ctx, httpx.HttpxContext, httpx.NewContext(r, 0)))
 		injectMap := injectMapPool.Get().(map[container.InstanceName]interface{})
-		instance := c.GetInstance(r.Context(), instanceName, injectMap)
+		instanceCtx, instanceSpan := tracex.Start(r.Context(), "container.GetInstance",
+			trace.WithAttributes(attribute.String("trinity.instance", string(instanceName))),
+		)
+		instance := c.GetInstance(instanceCtx, instanceName, injectMap)
+		instanceSpan.End()
 		defer func() {
 			for k, v := range injectMap {
 				c.Release(r.Context(), k, v)
@@ -185,54 +438,52 @@ func DIHandler(c *container.Container, instanceName container.InstanceName, func
 			httpx.HttpResponseErr(r.Context(), w, err)
 			return
 		}
+		defer httpx.CloseWebSocket(r.Context())
 		responseValue := currentMethod.Func.Call(inParams)
 		if isRaw {
+			httpx.CloseEventStream(r.Context())
 			return
 		}
-		switch len(responseValue) {
-		case 0:
-			httpx.HttpResponse(r.Context(), w, httpx.GetHTTPStatusCode(r.Context(), httpx.DefaultHttpSuccessCode), nil)
-			return
-		case 1:
-			if err, ok := responseValue[0].Interface().(error); ok {
-				if err != nil {
-					httpx.HttpResponseErr(r.Context(), w, err)
-					return
-				}
-			}
-			httpx.HttpResponse(r.Context(), w, httpx.GetHTTPStatusCode(r.Context(), httpx.DefaultHttpSuccessCode), responseValue[0].Interface())
-			return
-		case 2:
-			if err, ok := responseValue[1].Interface().(error); ok {
-				if err != nil {
-					httpx.HttpResponseErr(r.Context(), w, err)
-					return
-				}
-			}
-			httpx.HttpResponse(r.Context(), w, httpx.GetHTTPStatusCode(r.Context(), httpx.DefaultHttpSuccessCode), responseValue[0].Interface())
-			return
-		default:
-			httpx.HttpResponseErr(r.Context(), w, errors.New("wrong res type , first out should be response value , second out should be error "))
-			return
-		}
+		httpx.HandleResponse(r.Context(), w, responseValue)
 	}
 }
 
+// ServeHTTP start the http service and shutdown gracefully when receive the interrupt signal
+// the websocket connections are closed with wsx.CloseGoingAway on shutdown
+// the server timeouts are set by Config.ReadTimeout, Config.ReadHeaderTimeout, Config.WriteTimeout and Config.IdleTimeout
 func (t *trinity) ServeHTTP(ctx context.Context, addr ...string) error {
 	address := ":http"
 	if len(addr) > 0 {
 		address = addr[0]
 	}
+	server := &http.Server{
+		Addr:              address,
+		Handler:           t.mux,
+		ReadTimeout:       t.config.ReadTimeout,
+		ReadHeaderTimeout: t.config.ReadHeaderTimeout,
+		WriteTimeout:      t.config.WriteTimeout,
+		IdleTimeout:       t.config.IdleTimeout,
+	}
+	server.RegisterOnShutdown(func() {
+		wsx.CloseAll(wsx.CloseGoingAway, "server shutdown")
+	})
 	logx.FromCtx(ctx).Infof("http service started at %v", address)
-	gErr := make(chan error)
+	gErr := make(chan error, 1)
 	go func() {
-		gErr <- http.ListenAndServe(address, t.mux)
+		gErr <- server.ListenAndServe()
 	}()
-	go func() {
-		sigChan := make(chan os.Signal, 1)
-		signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
-		gErr <- fmt.Errorf("receive %s", <-sigChan)
-	}()
-
-	return <-gErr
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigChan)
+	select {
+	case err := <-gErr:
+		return err
+	case sig := <-sigChan:
+		shutdownCtx, cancel := context.WithTimeout(ctx, DefaultShutdownTimeout)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			return fmt.Errorf("receive %s, shutdown error: %w", sig, err)
+		}
+		return fmt.Errorf("receive %s", sig)
+	}
 }
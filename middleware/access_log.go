@@ -0,0 +1,96 @@
+package middleware
+
+import (
+	"math/rand"
+	"net/http"
+	"time"
+
+	"github.com/codeduckcloud/trinity-go/core/httpx"
+	"github.com/codeduckcloud/trinity-go/core/logx"
+	"github.com/go-chi/chi/v5"
+)
+
+var (
+	// the random source of the sampling, replaced in the test
+	_sampleRandom = rand.Float64
+)
+
+// AccessLogConfig the config of AccessLog
+type AccessLogConfig struct {
+	// the log level, see logx.ParseLevel
+	// default: info
+	Level string
+	// the ratio of the requests logged, in the range (0, 1]
+	// the 5xx responses are always logged
+	// default: 1, all the requests are logged
+	SampleRate float64
+	// the paths not logged, e.g. the health check, matched by the route pattern or the request path
+	ExcludePaths []string
+}
+
+// AccessLog log the request after it is handled with the method, route pattern, status, bytes written, latency, client ip and user agent
+// the logger in the ctx is used, the request is not logged if the logger not exist
+func AccessLog(config ...AccessLogConfig) func(next http.Handler) http.Handler {
+	var c AccessLogConfig
+	if len(config) > 0 {
+		c = config[0]
+	}
+	level, err := logx.ParseLevel(c.Level)
+	if c.Level == "" || err != nil {
+		level = logx.InfoLevel
+	}
+	excludes := make(map[string]bool, len(c.ExcludePaths))
+	for _, path := range c.ExcludePaths {
+		excludes[path] = true
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if excludes[r.URL.Path] {
+				next.ServeHTTP(w, r)
+				return
+			}
+			start := time.Now()
+			ww := httpx.WrapResponseWriter(w)
+			next.ServeHTTP(ww, r)
+			latency := time.Since(start)
+
+			route := routePattern(r)
+			if excludes[route] {
+				return
+			}
+			target := route
+			if target == "" {
+				target = r.URL.Path
+			}
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			if status < http.StatusInternalServerError && c.SampleRate > 0 && c.SampleRate < 1 && _sampleRandom() >= c.SampleRate {
+				return
+			}
+			logger, ok := logx.TryFromCtx(r.Context())
+			if !ok {
+				return
+			}
+			logger.WithFields(map[string]interface{}{
+				"method":     r.Method,
+				"route":      route,
+				"path":       r.URL.Path,
+				"status":     status,
+				"bytes":      ww.BytesWritten(),
+				"latency_ms": float64(latency.Microseconds()) / 1000,
+				"client_ip":  httpx.ClientIP(r),
+				"user_agent": r.UserAgent(),
+			}).Logf(level, "%-8v %-6v %-30v => %v %v", "access", r.Method, target, status, latency)
+		})
+	}
+}
+
+// routePattern the route pattern matched by chi, empty if no route matched
+func routePattern(r *http.Request) string {
+	if rctx := chi.RouteContext(r.Context()); rctx != nil {
+		return rctx.RoutePattern()
+	}
+	return ""
+}
@@ -0,0 +1,107 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/codeduckcloud/trinity-go/core/logx"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+type accessLogger struct {
+	*recordLogger
+	levels []logx.Level
+}
+
+func (l *accessLogger) WithFields(fields map[string]interface{}) logx.Logger {
+	l.recordLogger.WithFields(fields)
+	return l
+}
+
+func (l *accessLogger) Logf(level logx.Level, format string, args ...interface{}) {
+	l.levels = append(l.levels, level)
+	fmt.Fprintf(l.buf, format+"\n", args...)
+}
+
+func withSampleRandom(t *testing.T, v float64) {
+	original := _sampleRandom
+	_sampleRandom = func() float64 { return v }
+	t.Cleanup(func() {
+		_sampleRandom = original
+	})
+}
+
+func serveAccessLog(c AccessLogConfig, method, path string) (*accessLogger, *httptest.ResponseRecorder) {
+	logger := &accessLogger{recordLogger: newRecordLogger()}
+	router := chi.NewRouter()
+	router.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(logx.WithCtx(r.Context(), logger)))
+		})
+	})
+	router.Use(AccessLog(c))
+	router.Get("/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("hello"))
+	})
+	router.Get("/health", func(w http.ResponseWriter, r *http.Request) {})
+	router.Get("/error", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	})
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(method, path, nil)
+	req.Header.Set("User-Agent", "test-agent")
+	req.RemoteAddr = "10.0.0.1:5678"
+	router.ServeHTTP(rr, req)
+	return logger, rr
+}
+
+func TestAccessLog(t *testing.T) {
+	logger, rr := serveAccessLog(AccessLogConfig{}, http.MethodGet, "/users/1")
+	assert.Equal(t, "hello", rr.Body.String())
+	assert.Equal(t, []logx.Level{logx.InfoLevel}, logger.levels)
+	assert.Equal(t, http.MethodGet, logger.fields["method"])
+	assert.Equal(t, "/users/{id}", logger.fields["route"])
+	assert.Equal(t, "/users/1", logger.fields["path"])
+	assert.Equal(t, http.StatusOK, logger.fields["status"])
+	assert.Equal(t, int64(5), logger.fields["bytes"])
+	assert.Equal(t, "10.0.0.1", logger.fields["client_ip"])
+	assert.Equal(t, "test-agent", logger.fields["user_agent"])
+	assert.Contains(t, logger.fields, "latency_ms")
+	assert.Contains(t, logger.buf.String(), "/users/{id}")
+}
+
+func TestAccessLog_Level(t *testing.T) {
+	logger, _ := serveAccessLog(AccessLogConfig{Level: "debug"}, http.MethodGet, "/users/1")
+	assert.Equal(t, []logx.Level{logx.DebugLevel}, logger.levels)
+}
+
+func TestAccessLog_NotFound(t *testing.T) {
+	logger, rr := serveAccessLog(AccessLogConfig{}, http.MethodGet, "/unknown")
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.Equal(t, "", logger.fields["route"])
+	assert.Equal(t, http.StatusNotFound, logger.fields["status"])
+	assert.Contains(t, logger.buf.String(), "/unknown")
+}
+
+func TestAccessLog_ExcludePaths(t *testing.T) {
+	logger, _ := serveAccessLog(AccessLogConfig{ExcludePaths: []string{"/health", "/users/{id}"}}, http.MethodGet, "/health")
+	assert.Empty(t, logger.levels)
+	logger, _ = serveAccessLog(AccessLogConfig{ExcludePaths: []string{"/health", "/users/{id}"}}, http.MethodGet, "/users/1")
+	assert.Empty(t, logger.levels)
+}
+
+func TestAccessLog_Sampling(t *testing.T) {
+	withSampleRandom(t, 0.8)
+	c := AccessLogConfig{SampleRate: 0.5}
+	logger, _ := serveAccessLog(c, http.MethodGet, "/users/1")
+	assert.Empty(t, logger.levels)
+	logger, _ = serveAccessLog(c, http.MethodGet, "/error")
+	assert.Len(t, logger.levels, 1)
+
+	withSampleRandom(t, 0.2)
+	logger, _ = serveAccessLog(c, http.MethodGet, "/users/1")
+	assert.Len(t, logger.levels, 1)
+}
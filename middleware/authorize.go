@@ -0,0 +1,157 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/codeduckcloud/trinity-go/core/e"
+	"github.com/codeduckcloud/trinity-go/core/httpx"
+	"github.com/codeduckcloud/trinity-go/core/jwtx"
+)
+
+const (
+	DefaultRolesClaim       = "roles"
+	DefaultScopesClaim      = "scope"
+	DefaultPermissionsClaim = "permissions"
+)
+
+var (
+	_defaultPolicyEvaluator PolicyEvaluator = DefaultPolicyEvaluator{}
+)
+
+// Requirement the authorization requirement of the route
+// the request should have one of the roles, and all of the scopes and the permissions
+type Requirement struct {
+	Roles       []string
+	Scopes      []string
+	Permissions []string
+}
+
+func (r Requirement) IsEmpty() bool {
+	return len(r.Roles) == 0 && len(r.Scopes) == 0 && len(r.Permissions) == 0
+}
+
+// Merge merge the requirement, e.g. the route group requirement and the route requirement
+func (r Requirement) Merge(other Requirement) Requirement {
+	return Requirement{
+		Roles:       appendUnique(r.Roles, other.Roles...),
+		Scopes:      appendUnique(r.Scopes, other.Scopes...),
+		Permissions: appendUnique(r.Permissions, other.Permissions...),
+	}
+}
+
+// String e.g. `roles: admin|editor; scopes: read write`
+func (r Requirement) String() string {
+	parts := make([]string, 0, 3)
+	if len(r.Roles) > 0 {
+		parts = append(parts, "roles: "+strings.Join(r.Roles, "|"))
+	}
+	if len(r.Scopes) > 0 {
+		parts = append(parts, "scopes: "+strings.Join(r.Scopes, " "))
+	}
+	if len(r.Permissions) > 0 {
+		parts = append(parts, "permissions: "+strings.Join(r.Permissions, " "))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// PolicyEvaluator check the requirement against the request context
+// return e.ErrUnauthorized if not authenticated, e.ErrForbidden if the requirement not satisfied
+type PolicyEvaluator interface {
+	Evaluate(ctx context.Context, requirement Requirement) error
+}
+
+// SetPolicyEvaluator set the policy evaluator used by Authorize
+// default: DefaultPolicyEvaluator
+func SetPolicyEvaluator(p PolicyEvaluator) {
+	_defaultPolicyEvaluator = p
+}
+
+// DefaultPolicyEvaluator check the requirement against the claims set by Jwt
+type DefaultPolicyEvaluator struct {
+	// default: DefaultRolesClaim
+	RolesClaim string
+	// the space separated string is split, see jwtx.Claims.Fields
+	// default: DefaultScopesClaim
+	ScopesClaim string
+	// default: DefaultPermissionsClaim
+	PermissionsClaim string
+}
+
+func (p DefaultPolicyEvaluator) Evaluate(ctx context.Context, requirement Requirement) error {
+	claims, ok := jwtx.ClaimsFromCtx(ctx)
+	if !ok {
+		return e.ErrUnauthorized.New("claims missing")
+	}
+	if len(requirement.Roles) > 0 && !containsAny(claims.Strings(orDefault(p.RolesClaim, DefaultRolesClaim)), requirement.Roles) {
+		return e.ErrForbidden.New(fmt.Sprintf("one of the roles required: %v", strings.Join(requirement.Roles, ", ")))
+	}
+	if missing := missingOf(claims.Fields(orDefault(p.ScopesClaim, DefaultScopesClaim)), requirement.Scopes); len(missing) > 0 {
+		return e.ErrForbidden.New(fmt.Sprintf("scopes required: %v", strings.Join(missing, ", ")))
+	}
+	if missing := missingOf(claims.Strings(orDefault(p.PermissionsClaim, DefaultPermissionsClaim)), requirement.Permissions); len(missing) > 0 {
+		return e.ErrForbidden.New(fmt.Sprintf("permissions required: %v", strings.Join(missing, ", ")))
+	}
+	return nil
+}
+
+// Authorize check the requirement by the policy evaluator, the failure is responded by httpx.HttpResponseErr
+// the evaluator set by SetPolicyEvaluator is used if not passed
+func Authorize(requirement Requirement, evaluator ...PolicyEvaluator) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			p := _defaultPolicyEvaluator
+			if len(evaluator) > 0 {
+				p = evaluator[0]
+			}
+			if err := p.Evaluate(r.Context(), requirement); err != nil {
+				httpx.HttpResponseErr(r.Context(), w, err)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func orDefault(value, defaultValue string) string {
+	if value == "" {
+		return defaultValue
+	}
+	return value
+}
+
+func containsAny(values []string, targets []string) bool {
+	for _, target := range targets {
+		for _, value := range values {
+			if value == target {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func missingOf(values []string, targets []string) []string {
+	var missing []string
+	for _, target := range targets {
+		if !containsAny(values, []string{target}) {
+			missing = append(missing, target)
+		}
+	}
+	return missing
+}
+
+func appendUnique(values []string, items ...string) []string {
+	res := append([]string{}, values...)
+	for _, item := range items {
+		if !containsAny(res, []string{item}) {
+			res = append(res, item)
+		}
+	}
+	if len(res) == 0 {
+		return nil
+	}
+	return res
+}
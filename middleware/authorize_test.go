@@ -0,0 +1,84 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/codeduckcloud/trinity-go/core/e"
+	"github.com/codeduckcloud/trinity-go/core/jwtx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefaultPolicyEvaluator(t *testing.T) {
+	claims := jwtx.Claims{
+		"roles":       []interface{}{"editor"},
+		"scope":       "read write",
+		"permissions": []interface{}{"user:read"},
+	}
+	ctx := jwtx.WithClaims(context.Background(), claims)
+	tests := []struct {
+		name        string
+		ctx         context.Context
+		requirement Requirement
+		wantErr     error
+	}{
+		{name: "empty", ctx: ctx, requirement: Requirement{}},
+		{name: "satisfied", ctx: ctx, requirement: Requirement{Roles: []string{"admin", "editor"}, Scopes: []string{"read", "write"}, Permissions: []string{"user:read"}}},
+		{name: "role", ctx: ctx, requirement: Requirement{Roles: []string{"admin"}}, wantErr: e.ErrForbidden},
+		{name: "scope", ctx: ctx, requirement: Requirement{Scopes: []string{"read", "delete"}}, wantErr: e.ErrForbidden},
+		{name: "permission", ctx: ctx, requirement: Requirement{Permissions: []string{"user:write"}}, wantErr: e.ErrForbidden},
+		{name: "no claims", ctx: context.Background(), requirement: Requirement{Roles: []string{"admin"}}, wantErr: e.ErrUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := DefaultPolicyEvaluator{}.Evaluate(tt.ctx, tt.requirement)
+			if tt.wantErr == nil {
+				assert.Nil(t, err)
+				return
+			}
+			assert.True(t, errors.Is(err, tt.wantErr))
+		})
+	}
+	err := DefaultPolicyEvaluator{RolesClaim: "groups"}.Evaluate(jwtx.WithClaims(context.Background(), jwtx.Claims{"groups": "admin"}), Requirement{Roles: []string{"admin"}})
+	assert.Nil(t, err)
+}
+
+type denyEvaluator struct{}
+
+func (denyEvaluator) Evaluate(ctx context.Context, requirement Requirement) error {
+	return e.ErrForbidden.New("denied")
+}
+
+func TestAuthorize(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	requirement := Requirement{Roles: []string{"admin"}}
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r = r.WithContext(jwtx.WithClaims(r.Context(), jwtx.Claims{"roles": "admin"}))
+
+	w := httptest.NewRecorder()
+	Authorize(requirement)(next).ServeHTTP(w, r)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	w = httptest.NewRecorder()
+	Authorize(requirement, denyEvaluator{})(next).ServeHTTP(w, r)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	SetPolicyEvaluator(denyEvaluator{})
+	defer SetPolicyEvaluator(DefaultPolicyEvaluator{})
+	w = httptest.NewRecorder()
+	Authorize(requirement)(next).ServeHTTP(w, r)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestRequirement(t *testing.T) {
+	assert.True(t, Requirement{}.IsEmpty())
+	merged := Requirement{Roles: []string{"admin"}}.Merge(Requirement{Roles: []string{"admin", "editor"}, Scopes: []string{"read"}})
+	assert.Equal(t, Requirement{Roles: []string{"admin", "editor"}, Scopes: []string{"read"}}, merged)
+	assert.Equal(t, "roles: admin|editor; scopes: read", merged.String())
+	assert.Equal(t, "permissions: user:read", Requirement{Permissions: []string{"user:read"}}.String())
+}
@@ -0,0 +1,256 @@
+package middleware
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/codeduckcloud/trinity-go/core/requestid"
+)
+
+const (
+	AllowHeader                         = "Allow"
+	OriginHeader                        = "Origin"
+	VaryHeader                          = "Vary"
+	AccessControlRequestMethodHeader    = "Access-Control-Request-Method"
+	AccessControlRequestHeadersHeader   = "Access-Control-Request-Headers"
+	AccessControlAllowOriginHeader      = "Access-Control-Allow-Origin"
+	AccessControlAllowMethodsHeader     = "Access-Control-Allow-Methods"
+	AccessControlAllowHeadersHeader     = "Access-Control-Allow-Headers"
+	AccessControlAllowCredentialsHeader = "Access-Control-Allow-Credentials"
+	AccessControlExposeHeadersHeader    = "Access-Control-Expose-Headers"
+	AccessControlMaxAgeHeader           = "Access-Control-Max-Age"
+)
+
+var (
+	DefaultCORSMethods = []string{http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}
+	DefaultCORSHeaders = []string{"Accept", "Authorization", "Content-Type", requestid.HeaderName}
+)
+
+// CORSConfig the config of CORS
+type CORSConfig struct {
+	// the origins allowed, "*" allows any origin, one wildcard is supported in the origin
+	// e.g. "https://example.com", "https://*.example.com"
+	AllowedOrigins []string
+	// AllowOriginFunc check the origin not matched by AllowedOrigins
+	AllowOriginFunc func(r *http.Request, origin string) bool
+	// default: DefaultCORSMethods
+	AllowedMethods []string
+	// the request headers allowed, "*" allows any header requested
+	// default: DefaultCORSHeaders
+	AllowedHeaders []string
+	// the response headers exposed to the browser
+	ExposedHeaders []string
+	// allow the cookies and the authorization, the origin is echoed
+	// it could not be used with the "*" origin, the allowed origins should be listed
+	AllowCredentials bool
+	// how long the preflight response cached by the browser, not sent if 0
+	MaxAge time.Duration
+}
+
+type cors struct {
+	config         CORSConfig
+	allowAnyOrigin bool
+	origins        []string
+	wildcards      [][2]string
+	methods        map[string]bool
+	allowedMethods string
+	allowAnyHeader bool
+	headers        map[string]bool
+	allowedHeaders string
+	exposedHeaders string
+}
+
+func newCORS(c CORSConfig) *cors {
+	if len(c.AllowedMethods) == 0 {
+		c.AllowedMethods = DefaultCORSMethods
+	}
+	if len(c.AllowedHeaders) == 0 {
+		c.AllowedHeaders = DefaultCORSHeaders
+	}
+	res := &cors{
+		config:         c,
+		methods:        make(map[string]bool),
+		headers:        make(map[string]bool),
+		exposedHeaders: strings.Join(c.ExposedHeaders, ", "),
+	}
+	for _, origin := range c.AllowedOrigins {
+		origin = strings.ToLower(origin)
+		switch {
+		case origin == "*":
+			if c.AllowCredentials {
+				panic("middleware.CORS the origin \"*\" could not be used with AllowCredentials")
+			}
+			res.allowAnyOrigin = true
+		case strings.Contains(origin, "*"):
+			i := strings.Index(origin, "*")
+			res.wildcards = append(res.wildcards, [2]string{origin[:i], origin[i+1:]})
+		default:
+			res.origins = append(res.origins, origin)
+		}
+	}
+	methods := make([]string, 0, len(c.AllowedMethods))
+	for _, method := range c.AllowedMethods {
+		method = strings.ToUpper(method)
+		res.methods[method] = true
+		methods = append(methods, method)
+	}
+	res.allowedMethods = strings.Join(methods, ", ")
+	headers := make([]string, 0, len(c.AllowedHeaders))
+	for _, header := range c.AllowedHeaders {
+		if header == "*" {
+			res.allowAnyHeader = true
+			continue
+		}
+		header = http.CanonicalHeaderKey(header)
+		res.headers[header] = true
+		headers = append(headers, header)
+	}
+	res.allowedHeaders = strings.Join(headers, ", ")
+	return res
+}
+
+// CORS handle the cross-origin request, panic if the "*" origin is used with AllowCredentials
+// the preflight request, the OPTIONS with Access-Control-Request-Method, is responded with 204 and not passed to the next handler
+// the request from the origin not allowed is passed without the CORS headers, so that the browser rejects it
+func CORS(c CORSConfig) func(next http.Handler) http.Handler {
+	cs := newCORS(c)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if IsPreflight(r) {
+				cs.handlePreflight(w, r)
+				return
+			}
+			cs.handleActual(w, r)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// IsPreflight check whether the request is the CORS preflight request
+func IsPreflight(r *http.Request) bool {
+	return r.Method == http.MethodOptions && r.Header.Get(OriginHeader) != "" && r.Header.Get(AccessControlRequestMethodHeader) != ""
+}
+
+func (c *cors) handlePreflight(w http.ResponseWriter, r *http.Request) {
+	header := w.Header()
+	header.Add(VaryHeader, OriginHeader)
+	header.Add(VaryHeader, AccessControlRequestMethodHeader)
+	header.Add(VaryHeader, AccessControlRequestHeadersHeader)
+	defer w.WriteHeader(http.StatusNoContent)
+
+	origin := r.Header.Get(OriginHeader)
+	if !c.isOriginAllowed(r, origin) {
+		return
+	}
+	if !c.methods[strings.ToUpper(r.Header.Get(AccessControlRequestMethodHeader))] {
+		return
+	}
+	requestHeaders := r.Header.Get(AccessControlRequestHeadersHeader)
+	if !c.areHeadersAllowed(requestHeaders) {
+		return
+	}
+	c.setAllowOrigin(header, origin)
+	header.Set(AccessControlAllowMethodsHeader, c.allowedMethods)
+	if requestHeaders != "" {
+		if c.allowAnyHeader {
+			header.Set(AccessControlAllowHeadersHeader, requestHeaders)
+		} else {
+			header.Set(AccessControlAllowHeadersHeader, c.allowedHeaders)
+		}
+	}
+	if c.config.MaxAge > 0 {
+		header.Set(AccessControlMaxAgeHeader, strconv.Itoa(int(c.config.MaxAge.Seconds())))
+	}
+}
+
+func (c *cors) handleActual(w http.ResponseWriter, r *http.Request) {
+	origin := r.Header.Get(OriginHeader)
+	if origin == "" {
+		return
+	}
+	header := w.Header()
+	header.Add(VaryHeader, OriginHeader)
+	if !c.isOriginAllowed(r, origin) {
+		return
+	}
+	c.setAllowOrigin(header, origin)
+	if c.exposedHeaders != "" {
+		header.Set(AccessControlExposeHeadersHeader, c.exposedHeaders)
+	}
+}
+
+func (c *cors) setAllowOrigin(header http.Header, origin string) {
+	if c.allowAnyOrigin {
+		header.Set(AccessControlAllowOriginHeader, "*")
+	} else {
+		header.Set(AccessControlAllowOriginHeader, origin)
+	}
+	if c.config.AllowCredentials {
+		header.Set(AccessControlAllowCredentialsHeader, "true")
+	}
+}
+
+func (c *cors) isOriginAllowed(r *http.Request, origin string) bool {
+	if c.allowAnyOrigin {
+		return true
+	}
+	lower := strings.ToLower(origin)
+	for _, allowed := range c.origins {
+		if lower == allowed {
+			return true
+		}
+	}
+	for _, wildcard := range c.wildcards {
+		if len(lower) >= len(wildcard[0])+len(wildcard[1]) && strings.HasPrefix(lower, wildcard[0]) && strings.HasSuffix(lower, wildcard[1]) {
+			return true
+		}
+	}
+	if c.config.AllowOriginFunc != nil {
+		return c.config.AllowOriginFunc(r, origin)
+	}
+	return false
+}
+
+func (c *cors) areHeadersAllowed(requestHeaders string) bool {
+	if c.allowAnyHeader || requestHeaders == "" {
+		return true
+	}
+	for _, header := range strings.Split(requestHeaders, ",") {
+		header = http.CanonicalHeaderKey(strings.TrimSpace(header))
+		if header != "" && !c.headers[header] {
+			return false
+		}
+	}
+	return true
+}
+
+// CORSPreflight respond the OPTIONS request of the path, the preflight is handled by the CORS config of the method requested
+// the OPTIONS request without Access-Control-Request-Method is responded with the Allow header
+func CORSPreflight(configs map[string]CORSConfig) http.Handler {
+	handlers := make(map[string]*cors, len(configs))
+	methods := make([]string, 0, len(configs)+1)
+	for method, config := range configs {
+		method = strings.ToUpper(method)
+		handlers[method] = newCORS(config)
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+	allow := strings.Join(append(methods, http.MethodOptions), ", ")
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !IsPreflight(r) {
+			w.Header().Set(AllowHeader, allow)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		cs, ok := handlers[strings.ToUpper(r.Header.Get(AccessControlRequestMethodHeader))]
+		if !ok {
+			w.Header().Add(VaryHeader, OriginHeader)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		cs.handlePreflight(w, r)
+	})
+}
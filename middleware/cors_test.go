@@ -0,0 +1,173 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func serveCORS(c CORSConfig, r *http.Request) (*httptest.ResponseRecorder, bool) {
+	called := false
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		w.WriteHeader(http.StatusTeapot)
+	})
+	rr := httptest.NewRecorder()
+	CORS(c)(next).ServeHTTP(rr, r)
+	return rr, called
+}
+
+func newPreflight(origin, method, headers string) *http.Request {
+	r := httptest.NewRequest(http.MethodOptions, "/users", nil)
+	r.Header.Set(OriginHeader, origin)
+	r.Header.Set(AccessControlRequestMethodHeader, method)
+	if headers != "" {
+		r.Header.Set(AccessControlRequestHeadersHeader, headers)
+	}
+	return r
+}
+
+func TestCORS_Origins(t *testing.T) {
+	c := CORSConfig{
+		AllowedOrigins: []string{"https://example.com", "https://*.example.org"},
+		AllowOriginFunc: func(r *http.Request, origin string) bool {
+			return strings.HasSuffix(origin, ".internal")
+		},
+	}
+	tests := []struct {
+		origin  string
+		allowed bool
+	}{
+		{origin: "https://example.com", allowed: true},
+		{origin: "https://EXAMPLE.com", allowed: true},
+		{origin: "https://api.example.org", allowed: true},
+		{origin: "https://example.org", allowed: false},
+		{origin: "http://api.example.org", allowed: false},
+		{origin: "http://app.internal", allowed: true},
+		{origin: "https://evil.com", allowed: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.origin, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/users", nil)
+			r.Header.Set(OriginHeader, tt.origin)
+			rr, called := serveCORS(c, r)
+			assert.True(t, called)
+			assert.Equal(t, http.StatusTeapot, rr.Code)
+			assert.Equal(t, OriginHeader, rr.Header().Get(VaryHeader))
+			if tt.allowed {
+				assert.Equal(t, tt.origin, rr.Header().Get(AccessControlAllowOriginHeader))
+			} else {
+				assert.Empty(t, rr.Header().Get(AccessControlAllowOriginHeader))
+			}
+		})
+	}
+}
+
+func TestCORS_NoOrigin(t *testing.T) {
+	rr, called := serveCORS(CORSConfig{AllowedOrigins: []string{"*"}}, httptest.NewRequest(http.MethodGet, "/users", nil))
+	assert.True(t, called)
+	assert.Empty(t, rr.Header().Get(AccessControlAllowOriginHeader))
+	assert.Empty(t, rr.Header().Get(VaryHeader))
+}
+
+func TestCORS_AnyOrigin(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/users", nil)
+	r.Header.Set(OriginHeader, "https://example.com")
+	rr, _ := serveCORS(CORSConfig{AllowedOrigins: []string{"*"}, ExposedHeaders: []string{"Link", "X-Request-ID"}}, r)
+	assert.Equal(t, "*", rr.Header().Get(AccessControlAllowOriginHeader))
+	assert.Equal(t, "Link, X-Request-ID", rr.Header().Get(AccessControlExposeHeadersHeader))
+	assert.Empty(t, rr.Header().Get(AccessControlAllowCredentialsHeader))
+
+	// any origin with the credentials allows any site to read the response with the cookies
+	assert.Panics(t, func() { CORS(CORSConfig{AllowedOrigins: []string{"*"}, AllowCredentials: true}) })
+	assert.Panics(t, func() {
+		CORSPreflight(map[string]CORSConfig{http.MethodGet: {AllowedOrigins: []string{"*"}, AllowCredentials: true}})
+	})
+}
+
+func TestCORS_Preflight(t *testing.T) {
+	c := CORSConfig{
+		AllowedOrigins:   []string{"https://example.com"},
+		AllowedMethods:   []string{"get", "post"},
+		AllowedHeaders:   []string{"content-type", "authorization"},
+		AllowCredentials: true,
+		MaxAge:           10 * time.Minute,
+	}
+	rr, called := serveCORS(c, newPreflight("https://example.com", http.MethodPost, "Content-Type, authorization"))
+	assert.False(t, called)
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	assert.Equal(t, "https://example.com", rr.Header().Get(AccessControlAllowOriginHeader))
+	assert.Equal(t, "GET, POST", rr.Header().Get(AccessControlAllowMethodsHeader))
+	assert.Equal(t, "Content-Type, Authorization", rr.Header().Get(AccessControlAllowHeadersHeader))
+	assert.Equal(t, "true", rr.Header().Get(AccessControlAllowCredentialsHeader))
+	assert.Equal(t, "600", rr.Header().Get(AccessControlMaxAgeHeader))
+	assert.Equal(t, []string{OriginHeader, AccessControlRequestMethodHeader, AccessControlRequestHeadersHeader}, rr.Header().Values(VaryHeader))
+
+	tests := []struct {
+		name string
+		r    *http.Request
+	}{
+		{name: "origin not allowed", r: newPreflight("https://evil.com", http.MethodPost, "")},
+		{name: "method not allowed", r: newPreflight("https://example.com", http.MethodDelete, "")},
+		{name: "header not allowed", r: newPreflight("https://example.com", http.MethodPost, "X-Custom")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr, called := serveCORS(c, tt.r)
+			assert.False(t, called)
+			assert.Equal(t, http.StatusNoContent, rr.Code)
+			assert.Empty(t, rr.Header().Get(AccessControlAllowOriginHeader))
+			assert.Empty(t, rr.Header().Get(AccessControlAllowMethodsHeader))
+		})
+	}
+}
+
+func TestCORS_PreflightAnyHeader(t *testing.T) {
+	c := CORSConfig{AllowedOrigins: []string{"*"}, AllowedHeaders: []string{"*"}}
+	rr, _ := serveCORS(c, newPreflight("https://example.com", http.MethodGet, "X-Custom, X-Other"))
+	assert.Equal(t, "*", rr.Header().Get(AccessControlAllowOriginHeader))
+	assert.Equal(t, "X-Custom, X-Other", rr.Header().Get(AccessControlAllowHeadersHeader))
+	assert.Empty(t, rr.Header().Get(AccessControlMaxAgeHeader))
+}
+
+func TestCORS_OptionsNotPreflight(t *testing.T) {
+	r := httptest.NewRequest(http.MethodOptions, "/users", nil)
+	r.Header.Set(OriginHeader, "https://example.com")
+	_, called := serveCORS(CORSConfig{AllowedOrigins: []string{"*"}}, r)
+	assert.True(t, called)
+}
+
+func TestCORSPreflight(t *testing.T) {
+	h := CORSPreflight(map[string]CORSConfig{
+		http.MethodGet:    {AllowedOrigins: []string{"*"}},
+		http.MethodDelete: {AllowedOrigins: []string{"https://admin.example.com"}, AllowedMethods: []string{http.MethodDelete}},
+	})
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, newPreflight("https://example.com", http.MethodGet, ""))
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	assert.Equal(t, "*", rr.Header().Get(AccessControlAllowOriginHeader))
+
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, newPreflight("https://example.com", http.MethodDelete, ""))
+	assert.Empty(t, rr.Header().Get(AccessControlAllowOriginHeader))
+
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, newPreflight("https://admin.example.com", http.MethodDelete, ""))
+	assert.Equal(t, "https://admin.example.com", rr.Header().Get(AccessControlAllowOriginHeader))
+	assert.Equal(t, "DELETE", rr.Header().Get(AccessControlAllowMethodsHeader))
+
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, newPreflight("https://example.com", http.MethodPut, ""))
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	assert.Empty(t, rr.Header().Get(AccessControlAllowOriginHeader))
+
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodOptions, "/users", nil))
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	assert.Equal(t, "DELETE, GET, OPTIONS", rr.Header().Get(AllowHeader))
+}
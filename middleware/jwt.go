@@ -1,3 +1,107 @@
 package middleware
 
-func Jwt() {}
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/codeduckcloud/trinity-go/core/e"
+	"github.com/codeduckcloud/trinity-go/core/httpx"
+	"github.com/codeduckcloud/trinity-go/core/jwtx"
+	"github.com/codeduckcloud/trinity-go/core/logx"
+)
+
+const (
+	AuthorizationHeader   = "Authorization"
+	WWWAuthenticateHeader = "WWW-Authenticate"
+	BearerScheme          = "Bearer"
+)
+
+type JwtConfig struct {
+	// the verifier of the token, see jwtx.NewVerifier
+	Verifier *jwtx.Verifier
+	// TokenLookup get the token from the request
+	// default: BearerToken
+	TokenLookup func(r *http.Request) string
+	// Optional pass the request without the token, the invalid token is still rejected
+	Optional bool
+	// the realm in the WWW-Authenticate header
+	Realm string
+}
+
+// Jwt verify the token and set the claims in the request context, see jwtx.ClaimsFromCtx
+// the claims could be bound into the handler param by the `claims_param` tag
+// the missing or invalid token is responded as e.ErrUnauthorized with the WWW-Authenticate header
+func Jwt(c JwtConfig) func(next http.Handler) http.Handler {
+	if c.Verifier == nil {
+		panic("middleware.Jwt verifier is required")
+	}
+	if c.TokenLookup == nil {
+		c.TokenLookup = BearerToken
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token := c.TokenLookup(r)
+			if token == "" {
+				if c.Optional {
+					next.ServeHTTP(w, r)
+					return
+				}
+				w.Header().Set(WWWAuthenticateHeader, authenticateChallenge(c.Realm, ""))
+				httpx.HttpResponseErr(r.Context(), w, e.ErrUnauthorized.New("token missing"))
+				return
+			}
+			claims, err := c.Verifier.Verify(r.Context(), token)
+			if err != nil {
+				if logger, ok := logx.TryFromCtx(r.Context()); ok {
+					logger.Warnf("%-8v %-10v %-7v => %v", "http", "jwt", "invalid", err)
+				}
+				description := tokenErrDescription(err)
+				w.Header().Set(WWWAuthenticateHeader, authenticateChallenge(c.Realm, description))
+				httpx.HttpResponseErr(r.Context(), w, e.ErrUnauthorized.New(description))
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(jwtx.WithClaims(r.Context(), claims)))
+		})
+	}
+}
+
+// BearerToken get the bearer token from the Authorization header
+func BearerToken(r *http.Request) string {
+	auth := r.Header.Get(AuthorizationHeader)
+	if len(auth) <= len(BearerScheme)+1 || !strings.EqualFold(auth[:len(BearerScheme)], BearerScheme) || auth[len(BearerScheme)] != ' ' {
+		return ""
+	}
+	return strings.TrimSpace(auth[len(BearerScheme)+1:])
+}
+
+// tokenErrDescription the fixed description of the verify error responded to the client
+// the cause, e.g. the JWKS fetch error, is only logged
+func tokenErrDescription(err error) string {
+	switch {
+	case errors.Is(err, jwtx.ErrTokenExpired):
+		return "token expired"
+	case errors.Is(err, jwtx.ErrTokenNotValidYet):
+		return "token not valid yet"
+	case errors.Is(err, jwtx.ErrTokenMalformed):
+		return "token malformed"
+	default:
+		return "invalid token"
+	}
+}
+
+// authenticateChallenge the challenge of RFC 6750 3
+func authenticateChallenge(realm string, description string) string {
+	params := make([]string, 0, 3)
+	if realm != "" {
+		params = append(params, fmt.Sprintf("realm=%q", realm))
+	}
+	if description != "" {
+		params = append(params, `error="invalid_token"`, fmt.Sprintf("error_description=%q", description))
+	}
+	if len(params) == 0 {
+		return BearerScheme
+	}
+	return BearerScheme + " " + strings.Join(params, ", ")
+}
@@ -1,13 +1,101 @@
 package middleware
 
 import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
+	"github.com/codeduckcloud/trinity-go/core/e"
+	"github.com/codeduckcloud/trinity-go/core/httpx"
+	"github.com/codeduckcloud/trinity-go/core/jwtx"
 	"github.com/stretchr/testify/assert"
 )
 
+var testSecret = []byte("secret")
+
+func newTestJwt(t *testing.T, optional bool) http.Handler {
+	v, err := jwtx.NewVerifier(jwtx.Config{Secret: testSecret})
+	assert.Nil(t, err)
+	return Jwt(JwtConfig{Verifier: v, Optional: optional, Realm: "api"})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, _ := jwtx.ClaimsFromCtx(r.Context())
+		w.Write([]byte(claims.Subject()))
+	}))
+}
+
 func TestJwt(t *testing.T) {
-	assert.NotPanics(t, func() {
-		Jwt()
+	token, err := jwtx.Sign(jwtx.Claims{jwtx.ClaimSubject: "user-1"}, jwtx.HS256, testSecret, "")
+	assert.Nil(t, err)
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set(AuthorizationHeader, "Bearer "+token)
+	w := httptest.NewRecorder()
+	newTestJwt(t, false).ServeHTTP(w, r)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "user-1", w.Body.String())
+}
+
+func TestJwt_Unauthorized(t *testing.T) {
+	expired, _ := jwtx.Sign(jwtx.Claims{jwtx.ClaimExpiresAt: time.Now().Add(-time.Hour).Unix()}, jwtx.HS256, testSecret, "")
+	forged, _ := jwtx.Sign(jwtx.Claims{}, jwtx.HS256, []byte("forged"), "")
+	tests := []struct {
+		name          string
+		authorization string
+		wantChallenge string
+	}{
+		{name: "missing", authorization: "", wantChallenge: `Bearer realm="api"`},
+		{name: "basic", authorization: "Basic dXNlcjpwYXNz", wantChallenge: `Bearer realm="api"`},
+		{name: "malformed", authorization: "Bearer abc", wantChallenge: `Bearer realm="api", error="invalid_token", error_description="token malformed"`},
+		{name: "expired", authorization: "Bearer " + expired, wantChallenge: `Bearer realm="api", error="invalid_token", error_description="token expired"`},
+		{name: "invalid signature", authorization: "Bearer " + forged, wantChallenge: `Bearer realm="api", error="invalid_token", error_description="invalid token"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.authorization != "" {
+				r.Header.Set(AuthorizationHeader, tt.authorization)
+			}
+			w := httptest.NewRecorder()
+			newTestJwt(t, false).ServeHTTP(w, r)
+			assert.Equal(t, http.StatusUnauthorized, w.Code)
+			assert.Equal(t, tt.wantChallenge, w.Header().Get(WWWAuthenticateHeader))
+			var resp httpx.Response
+			assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			assert.Equal(t, e.UnauthorizedError, resp.Error.Code)
+			assert.NotContains(t, w.Body.String(), "signature")
+		})
+	}
+}
+
+func TestJwt_Optional(t *testing.T) {
+	w := httptest.NewRecorder()
+	newTestJwt(t, true).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Body.String())
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set(AuthorizationHeader, "Bearer abc")
+	w = httptest.NewRecorder()
+	newTestJwt(t, true).ServeHTTP(w, r)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestJwt_NoVerifier(t *testing.T) {
+	assert.Panics(t, func() {
+		Jwt(JwtConfig{})
 	})
 }
+
+func TestBearerToken(t *testing.T) {
+	for header, want := range map[string]string{
+		"Bearer abc": "abc",
+		"bearer abc": "abc",
+		"Bearer":     "",
+		"Bearerabc":  "",
+		"Basic abc":  "",
+	} {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set(AuthorizationHeader, header)
+		assert.Equal(t, want, BearerToken(r), header)
+	}
+}
@@ -0,0 +1,101 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/codeduckcloud/trinity-go/core/httpx"
+	"github.com/codeduckcloud/trinity-go/core/metrics"
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	// UnmatchedRoute the route label of the request not matching any route, to keep the cardinality bounded
+	UnmatchedRoute = "unmatched"
+	// OtherMethod the method label of the non-standard method, to keep the cardinality bounded
+	OtherMethod = "OTHER"
+)
+
+// MetricsConfig the config of Metrics
+type MetricsConfig struct {
+	// default: metrics.DefaultRegistry
+	Registry *metrics.Registry
+	// the buckets of the latency histogram in seconds
+	// default: metrics.DefaultBuckets
+	Buckets []float64
+}
+
+// Metrics collect the request metrics labeled by the route pattern instead of the raw path
+//
+//	trinity_http_requests_total{method,route,status}
+//	trinity_http_request_duration_seconds{method,route,status}
+//	trinity_http_requests_in_flight{method,route}
+//
+// the metrics registered in the registry already are reused, see metrics.GetOrRegister
+func Metrics(config ...MetricsConfig) func(next http.Handler) http.Handler {
+	var c MetricsConfig
+	if len(config) > 0 {
+		c = config[0]
+	}
+	if c.Registry == nil {
+		c.Registry = metrics.DefaultRegistry()
+	}
+	requests := metrics.GetOrRegister(c.Registry, metrics.NewCounter("trinity_http_requests_total", "The number of the http requests handled.", "method", "route", "status"))
+	durations := metrics.GetOrRegister(c.Registry, metrics.NewHistogram("trinity_http_request_duration_seconds", "The latency of the http requests.", c.Buckets, "method", "route", "status"))
+	inFlight := metrics.GetOrRegister(c.Registry, metrics.NewGauge("trinity_http_requests_in_flight", "The number of the http requests being handled.", "method", "route"))
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			method := methodLabel(r.Method)
+			route := matchRoutePattern(r)
+			inFlight.Inc(method, route)
+			defer inFlight.Dec(method, route)
+
+			start := time.Now()
+			ww := httpx.WrapResponseWriter(w)
+			next.ServeHTTP(ww, r)
+
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			statusLabel := strconv.Itoa(status)
+			requests.Inc(method, route, statusLabel)
+			durations.Observe(time.Since(start).Seconds(), method, route, statusLabel)
+		})
+	}
+}
+
+// methodLabel the method label, OtherMethod for the non-standard method
+func methodLabel(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		return method
+	default:
+		return OtherMethod
+	}
+}
+
+// matchRoutePattern match the route pattern before the request routed, UnmatchedRoute if no route matched
+func matchRoutePattern(r *http.Request) string {
+	rctx := chi.RouteContext(r.Context())
+	if rctx == nil || rctx.Routes == nil {
+		return UnmatchedRoute
+	}
+	if pattern := rctx.RoutePattern(); pattern != "" {
+		return pattern
+	}
+	path := rctx.RoutePath
+	if path == "" {
+		path = r.URL.RawPath
+		if path == "" {
+			path = r.URL.Path
+		}
+	}
+	tctx := chi.NewRouteContext()
+	if !rctx.Routes.Match(tctx, r.Method, path) {
+		return UnmatchedRoute
+	}
+	return tctx.RoutePattern()
+}
@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/codeduckcloud/trinity-go/core/metrics"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetrics(t *testing.T) {
+	registry := metrics.NewRegistry()
+	router := chi.NewRouter()
+	router.Use(Metrics(MetricsConfig{Registry: registry}))
+	var inFlight string
+	router.Get("/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		buf := &bytes.Buffer{}
+		registry.WriteText(buf)
+		inFlight = buf.String()
+		w.WriteHeader(http.StatusCreated)
+	})
+	router.Get("/metrics", registry.Handler().ServeHTTP)
+
+	for _, path := range []string{"/users/1", "/users/2", "/unknown"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	assert.Contains(t, inFlight, `trinity_http_requests_in_flight{method="GET",route="/users/{id}"} 1`)
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	text := rr.Body.String()
+	assert.Equal(t, metrics.ContentType, rr.Header().Get("Content-Type"))
+	assert.Contains(t, text, `trinity_http_requests_total{method="GET",route="/users/{id}",status="201"} 2`)
+	assert.Contains(t, text, `trinity_http_requests_total{method="GET",route="unmatched",status="404"} 1`)
+	assert.Contains(t, text, `trinity_http_request_duration_seconds_count{method="GET",route="/users/{id}",status="201"} 2`)
+	assert.Contains(t, text, `trinity_http_requests_in_flight{method="GET",route="/users/{id}"} 0`)
+	assert.NotContains(t, text, "/users/1")
+}
+
+func TestMetrics_WithoutRouter(t *testing.T) {
+	registry := metrics.NewRegistry()
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	Metrics(MetricsConfig{Registry: registry})(next).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	buf := &bytes.Buffer{}
+	assert.NoError(t, registry.WriteText(buf))
+	assert.Contains(t, buf.String(), `trinity_http_requests_total{method="GET",route="unmatched",status="200"} 1`)
+}
+
+func TestMetrics_MethodLabel(t *testing.T) {
+	registry := metrics.NewRegistry()
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	// the metrics are reused by the second middleware
+	Metrics(MetricsConfig{Registry: registry})(next).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("FOO", "/", nil))
+	Metrics(MetricsConfig{Registry: registry})(next).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("BAR", "/", nil))
+
+	buf := &bytes.Buffer{}
+	assert.NoError(t, registry.WriteText(buf))
+	assert.Contains(t, buf.String(), `trinity_http_requests_total{method="OTHER",route="unmatched",status="200"} 2`)
+	assert.NotContains(t, buf.String(), "FOO")
+}
@@ -0,0 +1,125 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/codeduckcloud/trinity-go/core/e"
+	"github.com/codeduckcloud/trinity-go/core/httpx"
+	"github.com/codeduckcloud/trinity-go/core/jwtx"
+	"github.com/codeduckcloud/trinity-go/core/logx"
+	"github.com/codeduckcloud/trinity-go/core/ratelimit"
+)
+
+const (
+	RateLimitLimitHeader     = "X-RateLimit-Limit"
+	RateLimitRemainingHeader = "X-RateLimit-Remaining"
+	RateLimitResetHeader     = "X-RateLimit-Reset"
+	RetryAfterHeader         = "Retry-After"
+	APIKeyHeader             = "X-API-Key"
+)
+
+// KeyFunc the key of the request to be limited, the request with the empty key is not limited
+type KeyFunc func(r *http.Request) string
+
+// KeyByIP limit by the client ip, see httpx.ClientIP
+func KeyByIP(r *http.Request) string {
+	return "ip:" + httpx.ClientIP(r)
+}
+
+// KeyBySubject limit by the sub claim verified by middleware.Jwt, the client ip is used if no subject
+func KeyBySubject(r *http.Request) string {
+	if claims, ok := jwtx.ClaimsFromCtx(r.Context()); ok && claims.Subject() != "" {
+		return "sub:" + claims.Subject()
+	}
+	return KeyByIP(r)
+}
+
+// KeyByHeader limit by the sha256 of the header, e.g. APIKeyHeader, the client ip is used if no header
+// the header should be authenticated before the rate limit, otherwise the client could rotate the random values to bypass the limit
+// the value is hashed, so the secret, e.g. the api key, is not kept in the store
+func KeyByHeader(name string) KeyFunc {
+	return func(r *http.Request) string {
+		if value := r.Header.Get(name); value != "" {
+			sum := sha256.Sum256([]byte(value))
+			return "header:" + hex.EncodeToString(sum[:])
+		}
+		return KeyByIP(r)
+	}
+}
+
+// RateLimitConfig the config of RateLimit
+type RateLimitConfig struct {
+	Limit ratelimit.Limit
+	// default: KeyByIP
+	KeyFunc KeyFunc
+	// the prefix of the key in the store, set it to separate the limits sharing the same store
+	Name string
+	// default: ratelimit.NewMemoryStore
+	Store ratelimit.Store
+	// default: ratelimit.SystemClock
+	Clock ratelimit.Clock
+	// allow the request if the store failed, the request is responded e.ErrUnknown by default
+	FailOpen bool
+}
+
+// RateLimit limit the rate of the requests by the key
+// the X-RateLimit-* headers are set, the request exceeded the limit is responded e.ErrTooManyRequests with Retry-After
+// the request is rejected if the store failed, unless RateLimitConfig.FailOpen
+//
+// the limit is shared by the routes using the same middleware, e.g.
+//
+//	limit := middleware.RateLimit(middleware.RateLimitConfig{Limit: ratelimit.Limit{Requests: 100, Period: time.Minute}})
+func RateLimit(c RateLimitConfig) func(next http.Handler) http.Handler {
+	if c.KeyFunc == nil {
+		c.KeyFunc = KeyByIP
+	}
+	limiter := ratelimit.New(ratelimit.Config{
+		Limit: c.Limit,
+		Store: c.Store,
+		Clock: c.Clock,
+	})
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := c.KeyFunc(r)
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if c.Name != "" {
+				key = c.Name + ":" + key
+			}
+			res, err := limiter.Allow(r.Context(), key)
+			if err != nil {
+				if !c.FailOpen {
+					httpx.HttpResponseErr(r.Context(), w, e.ErrUnknown.Wrap(err, "rate limit store failed"))
+					return
+				}
+				if logger, ok := logx.TryFromCtx(r.Context()); ok {
+					logger.Errorf("%-8v %-10v %-7v => %v, err: %v", "http", "ratelimit", "failed", key, err)
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+			header := w.Header()
+			header.Set(RateLimitLimitHeader, strconv.Itoa(res.Limit))
+			header.Set(RateLimitRemainingHeader, strconv.Itoa(res.Remaining))
+			header.Set(RateLimitResetHeader, strconv.Itoa(ceilSeconds(res.ResetAfter)))
+			if !res.Allowed {
+				retryAfter := strconv.Itoa(ceilSeconds(res.RetryAfter))
+				header.Set(RetryAfterHeader, retryAfter)
+				httpx.HttpResponseErr(r.Context(), w, e.ErrTooManyRequests.New("retry after "+retryAfter+" seconds"))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,145 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/codeduckcloud/trinity-go/core/httpx"
+	"github.com/codeduckcloud/trinity-go/core/jwtx"
+	"github.com/codeduckcloud/trinity-go/core/ratelimit"
+	"github.com/stretchr/testify/assert"
+)
+
+func serveRateLimit(h http.Handler, r *http.Request) *httptest.ResponseRecorder {
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, r)
+	return rr
+}
+
+func newRateLimitRequest(ip string) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.RemoteAddr = ip + ":5678"
+	return r
+}
+
+func TestRateLimit(t *testing.T) {
+	clock := ratelimit.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+	h := RateLimit(RateLimitConfig{
+		Limit: ratelimit.Limit{Requests: 2, Period: 10 * time.Second},
+		Clock: clock,
+	})(next)
+
+	rr := serveRateLimit(h, newRateLimitRequest("10.0.0.1"))
+	assert.Equal(t, http.StatusTeapot, rr.Code)
+	assert.Equal(t, "2", rr.Header().Get(RateLimitLimitHeader))
+	assert.Equal(t, "1", rr.Header().Get(RateLimitRemainingHeader))
+	assert.Equal(t, "5", rr.Header().Get(RateLimitResetHeader))
+	assert.Empty(t, rr.Header().Get(RetryAfterHeader))
+
+	serveRateLimit(h, newRateLimitRequest("10.0.0.1"))
+	rr = serveRateLimit(h, newRateLimitRequest("10.0.0.1"))
+	assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+	assert.Equal(t, "0", rr.Header().Get(RateLimitRemainingHeader))
+	assert.Equal(t, "5", rr.Header().Get(RetryAfterHeader))
+	var resp httpx.Response
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	assert.Equal(t, http.StatusTooManyRequests, resp.Status)
+	b, _ := json.Marshal(resp.Error)
+	assert.Contains(t, string(b), "429001")
+
+	// the other ip has its own limit
+	rr = serveRateLimit(h, newRateLimitRequest("10.0.0.2"))
+	assert.Equal(t, http.StatusTeapot, rr.Code)
+
+	clock.Advance(5 * time.Second)
+	rr = serveRateLimit(h, newRateLimitRequest("10.0.0.1"))
+	assert.Equal(t, http.StatusTeapot, rr.Code)
+}
+
+func TestRateLimit_KeyFunc(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	r := newRateLimitRequest("10.0.0.1")
+	assert.Equal(t, "ip:10.0.0.1", KeyByIP(r))
+	assert.Equal(t, "ip:10.0.0.1", KeyBySubject(r))
+	assert.Equal(t, "ip:10.0.0.1", KeyByHeader(APIKeyHeader)(r))
+	r.Header.Set(APIKeyHeader, "key-1")
+	// sha256 of key-1
+	assert.Equal(t, "header:be2974546978e3739e6d6da85c4be9f334ce32df2b9fd4b6ff1b55c0d57e9d44", KeyByHeader(APIKeyHeader)(r))
+	r = r.WithContext(jwtx.WithClaims(r.Context(), jwtx.Claims{jwtx.ClaimSubject: "user-1"}))
+	assert.Equal(t, "sub:user-1", KeyBySubject(r))
+
+	// the request without the header is limited by the client ip
+	h := RateLimit(RateLimitConfig{
+		Limit:   ratelimit.Limit{Requests: 1, Period: time.Minute},
+		KeyFunc: KeyByHeader(APIKeyHeader),
+	})(next)
+	assert.Equal(t, http.StatusOK, serveRateLimit(h, newRateLimitRequest("10.0.0.1")).Code)
+	assert.Equal(t, http.StatusTooManyRequests, serveRateLimit(h, newRateLimitRequest("10.0.0.1")).Code)
+
+	// the forged X-Forwarded-For is ignored without the trusted proxy
+	r = newRateLimitRequest("10.0.0.1")
+	r.Header.Set(httpx.ForwardedForHeader, "203.0.113.1")
+	assert.Equal(t, http.StatusTooManyRequests, serveRateLimit(h, r).Code)
+
+	// the request with the empty key is not limited
+	h = RateLimit(RateLimitConfig{
+		Limit:   ratelimit.Limit{Requests: 1, Period: time.Minute},
+		KeyFunc: func(r *http.Request) string { return "" },
+	})(next)
+	for i := 0; i < 3; i++ {
+		rr := serveRateLimit(h, newRateLimitRequest("10.0.0.1"))
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Empty(t, rr.Header().Get(RateLimitLimitHeader))
+	}
+}
+
+func TestRateLimit_SharedStore(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	store := ratelimit.NewMemoryStore()
+	limit := ratelimit.Limit{Requests: 1, Period: time.Minute}
+	login := RateLimit(RateLimitConfig{Limit: limit, Store: store, Name: "login"})(next)
+	signup := RateLimit(RateLimitConfig{Limit: limit, Store: store, Name: "signup"})(next)
+
+	assert.Equal(t, http.StatusOK, serveRateLimit(login, newRateLimitRequest("10.0.0.1")).Code)
+	assert.Equal(t, http.StatusOK, serveRateLimit(signup, newRateLimitRequest("10.0.0.1")).Code)
+	assert.Equal(t, http.StatusTooManyRequests, serveRateLimit(login, newRateLimitRequest("10.0.0.1")).Code)
+	assert.Equal(t, 2, store.Len())
+}
+
+type failedStore struct{}
+
+func (failedStore) Update(ctx context.Context, key string, ttl time.Duration, fn func(state ratelimit.State, exist bool) ratelimit.State) error {
+	return errors.New("connection refused")
+}
+
+func TestRateLimit_StoreFailed(t *testing.T) {
+	called := false
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	})
+	h := RateLimit(RateLimitConfig{
+		Limit: ratelimit.Limit{Requests: 1, Period: time.Minute},
+		Store: failedStore{},
+	})(next)
+	rr := serveRateLimit(h, newRateLimitRequest("10.0.0.1"))
+	assert.False(t, called)
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+
+	h = RateLimit(RateLimitConfig{
+		Limit:    ratelimit.Limit{Requests: 1, Period: time.Minute},
+		Store:    failedStore{},
+		FailOpen: true,
+	})(next)
+	rr = serveRateLimit(h, newRateLimitRequest("10.0.0.1"))
+	assert.True(t, called)
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
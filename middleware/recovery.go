@@ -1,25 +1,92 @@
 package middleware
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"runtime/debug"
 
+	"github.com/codeduckcloud/trinity-go/core/e"
 	"github.com/codeduckcloud/trinity-go/core/httpx"
+	"github.com/codeduckcloud/trinity-go/core/logx"
+	"github.com/codeduckcloud/trinity-go/core/requestid"
 )
 
-func Recovery() func(next http.Handler) http.Handler {
+// PanicInfo the panic recovered from the request
+type PanicInfo struct {
+	Value     interface{}
+	Stack     []byte
+	Method    string
+	Path      string
+	RequestID string
+	Request   *http.Request
+}
+
+// PanicHook report the panic, e.g. to the error tracker
+// the panic in the hook is recovered and logged
+type PanicHook func(ctx context.Context, info PanicInfo)
+
+// Recovery recover the panic, log it with the stack and respond e.ErrPanic
+// http.ErrAbortHandler is re-panicked to abort the response silently
+// if the response is already started, the connection is aborted instead of writing the second response
+func Recovery(hooks ...PanicHook) func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ww := httpx.WrapResponseWriter(w)
 			defer func() {
-				if err := recover(); err != nil {
-					logMessage := fmt.Sprintf("Recovered from HTTP Request %v %v \n", r.Method, r.URL)
-					logMessage += fmt.Sprintf("Trace: %s\n", err)
-					logMessage += fmt.Sprintf("\n%s", debug.Stack())
-					httpx.HttpResponseErr(r.Context(), w, fmt.Errorf("panic %v", err))
+				err := recover()
+				if err == nil {
+					return
+				}
+				if err == http.ErrAbortHandler {
+					panic(err)
+				}
+				stack := debug.Stack()
+				if recovered, ok := err.(*RecoveredPanic); ok {
+					err, stack = recovered.Value, recovered.Stack
+				}
+				info := PanicInfo{
+					Value:     err,
+					Stack:     stack,
+					Method:    r.Method,
+					Path:      r.URL.Path,
+					RequestID: requestid.FromCtx(r.Context()),
+					Request:   r,
+				}
+				logPanic(r.Context(), info)
+				for _, hook := range hooks {
+					runPanicHook(r.Context(), hook, info)
 				}
+				if ww.Written() {
+					panic(http.ErrAbortHandler)
+				}
+				httpx.HttpResponseErr(r.Context(), ww, e.ErrPanic.WithMessage(fmt.Sprintf("panic %v", err)))
 			}()
-			next.ServeHTTP(w, r)
+			next.ServeHTTP(ww, r)
 		})
 	}
 }
+
+func logPanic(ctx context.Context, info PanicInfo) {
+	logger, ok := logx.TryFromCtx(ctx)
+	if !ok {
+		return
+	}
+	logger.WithFields(map[string]interface{}{
+		"method":     info.Method,
+		"path":       info.Path,
+		"request_id": info.RequestID,
+		"stack":      string(info.Stack),
+	}).Errorf("%-8v %-10v %-7v => %v", "http", "panic", "recover", info.Value)
+}
+
+func runPanicHook(ctx context.Context, hook PanicHook, info PanicInfo) {
+	defer func() {
+		if err := recover(); err != nil {
+			if logger, ok := logx.TryFromCtx(ctx); ok {
+				logger.Errorf("%-8v %-10v %-7v => %v", "http", "panic", "hook", err)
+			}
+		}
+	}()
+	hook(ctx, info)
+}
@@ -1,11 +1,15 @@
 package middleware
 
 import (
+	"bytes"
+	"context"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
-	"github.com/codeduckcloud/trinity-go/core/httpx"
+	"github.com/codeduckcloud/trinity-go/core/logx"
+	"github.com/codeduckcloud/trinity-go/core/requestid"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -30,6 +34,107 @@ func TestRecovery_Panic(t *testing.T) {
 	rr := httptest.NewRecorder()
 	req := httptest.NewRequest(http.MethodGet, "/", nil)
 	mw.ServeHTTP(rr, req)
-	assert.Equal(t, httpx.DefaultHttpErrorCode, rr.Code)
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
 	assert.Contains(t, rr.Body.String(), "panic")
 }
+
+type recordLogger struct {
+	logx.Logger
+	fields map[string]interface{}
+	buf    *bytes.Buffer
+}
+
+func newRecordLogger() *recordLogger {
+	return &recordLogger{
+		Logger: logx.NewLogrusLogger(),
+		fields: make(map[string]interface{}),
+		buf:    &bytes.Buffer{},
+	}
+}
+
+func (l *recordLogger) WithField(key string, value interface{}) logx.Logger {
+	l.fields[key] = value
+	return l
+}
+
+func (l *recordLogger) WithFields(fields map[string]interface{}) logx.Logger {
+	for k, v := range fields {
+		l.fields[k] = v
+	}
+	return l
+}
+
+func (l *recordLogger) Errorf(format string, args ...interface{}) {
+	fmt.Fprintf(l.buf, "ERROR "+format+"\n", args...)
+}
+
+func TestRecovery_LogAndHooks(t *testing.T) {
+	logger := newRecordLogger()
+	var got PanicInfo
+	hooks := []PanicHook{
+		func(ctx context.Context, info PanicInfo) {
+			panic("hook failed")
+		},
+		func(ctx context.Context, info PanicInfo) {
+			got = info
+		},
+	}
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+	mw := Recovery(hooks...)(next)
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/users/1", nil)
+	req = req.WithContext(logx.WithCtx(requestid.WithCtx(req.Context(), "req-1"), logger))
+	mw.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	assert.Equal(t, "boom", got.Value)
+	assert.Equal(t, http.MethodPost, got.Method)
+	assert.Equal(t, "/users/1", got.Path)
+	assert.Equal(t, "req-1", got.RequestID)
+	assert.NotEmpty(t, got.Stack)
+
+	assert.Equal(t, http.MethodPost, logger.fields["method"])
+	assert.Equal(t, "/users/1", logger.fields["path"])
+	assert.Equal(t, "req-1", logger.fields["request_id"])
+	assert.Contains(t, logger.fields["stack"], "TestRecovery_LogAndHooks")
+	assert.Contains(t, logger.buf.String(), "boom")
+	assert.Contains(t, logger.buf.String(), "hook failed")
+}
+
+func TestRecovery_AbortHandler(t *testing.T) {
+	called := false
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	})
+	mw := Recovery(func(ctx context.Context, info PanicInfo) {
+		called = true
+	})(next)
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		mw.ServeHTTP(rr, req)
+	})
+	assert.False(t, called)
+	assert.Empty(t, rr.Body.String())
+}
+
+func TestRecovery_AlreadyWritten(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("partial"))
+		panic("boom")
+	})
+	mw := Recovery()(next)
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		mw.ServeHTTP(rr, req)
+	})
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "partial", rr.Body.String())
+}
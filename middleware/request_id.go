@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/codeduckcloud/trinity-go/core/logx"
+	"github.com/codeduckcloud/trinity-go/core/requestid"
+)
+
+// RequestID read the X-Request-ID of the request or generate one, set it in the ctx and echo it in the response header
+// the session logger in the ctx is attached with the request_id field
+// the invalid request id, see requestid.IsValid, is replaced by the generated one
+func RequestID() func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := r.Header.Get(requestid.HeaderName)
+			if !requestid.IsValid(id) {
+				id = requestid.New()
+			}
+			ctx := requestid.WithCtx(r.Context(), id)
+			if logger, ok := logx.TryFromCtx(ctx); ok {
+				ctx = logx.WithCtx(ctx, logger.WithField("request_id", id))
+			}
+			w.Header().Set(requestid.HeaderName, id)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/codeduckcloud/trinity-go/core/logx"
+	"github.com/codeduckcloud/trinity-go/core/requestid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestID(t *testing.T) {
+	tests := []struct {
+		name     string
+		incoming string
+		keep     bool
+	}{
+		{name: "incoming", incoming: "req-1", keep: true},
+		{name: "generated", incoming: "", keep: false},
+		{name: "invalid", incoming: "req 1\n", keep: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := newRecordLogger()
+			var got string
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = requestid.FromCtx(r.Context())
+				logx.FromCtx(r.Context()).Errorf("handled")
+			})
+			rr := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.incoming != "" {
+				req.Header.Set(requestid.HeaderName, tt.incoming)
+			}
+			req = req.WithContext(logx.WithCtx(req.Context(), logger))
+			RequestID()(next).ServeHTTP(rr, req)
+
+			assert.NotEmpty(t, got)
+			if tt.keep {
+				assert.Equal(t, tt.incoming, got)
+			} else {
+				assert.NotEqual(t, tt.incoming, got)
+			}
+			assert.Equal(t, got, rr.Header().Get(requestid.HeaderName))
+			assert.Equal(t, got, logger.fields["request_id"])
+		})
+	}
+}
+
+func TestRequestID_WithoutLogger(t *testing.T) {
+	var got string
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = requestid.FromCtx(r.Context())
+	})
+	rr := httptest.NewRecorder()
+	RequestID()(next).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.NotEmpty(t, got)
+	assert.Equal(t, got, rr.Header().Get(requestid.HeaderName))
+}
@@ -0,0 +1,136 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/codeduckcloud/trinity-go/core/e"
+	"github.com/codeduckcloud/trinity-go/core/httpx"
+)
+
+// Timeout set the deadline of the request context, e.ErrTimeout is responded if the handler not committed the response before the deadline
+// the response is streamed once the handler wrote the header, e.g. the file or the io.Reader result is not buffered in memory
+// the handler is stopped when the deadline exceeded or the client gone, the writes after that are discarded with http.ErrHandlerTimeout
+// the panic of the handler is re-panicked as *RecoveredPanic with the stack of the handler goroutine
+// the long-lived routes, e.g. the websocket and the event stream, should not be wrapped, as they are cancelled by the deadline
+func Timeout(timeout time.Duration) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if timeout <= 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+
+			tw := &timeoutWriter{
+				w:      w,
+				header: make(http.Header),
+			}
+			done := make(chan struct{})
+			panicChan := make(chan interface{}, 1)
+			go func() {
+				defer func() {
+					if p := recover(); p != nil {
+						if p != http.ErrAbortHandler {
+							p = &RecoveredPanic{Value: p, Stack: debug.Stack()}
+						}
+						panicChan <- p
+					}
+				}()
+				next.ServeHTTP(tw, r.WithContext(ctx))
+				close(done)
+			}()
+			select {
+			case p := <-panicChan:
+				panic(p)
+			case <-done:
+				tw.mu.Lock()
+				defer tw.mu.Unlock()
+				if !tw.wroteHeader {
+					tw.writeHeaderLocked(http.StatusOK)
+				}
+			case <-ctx.Done():
+				tw.mu.Lock()
+				defer tw.mu.Unlock()
+				tw.timedOut = true
+				// the client gone or the response already committed, nothing could be responded
+				if tw.wroteHeader || !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+					return
+				}
+				httpx.HttpResponseErr(r.Context(), w, e.ErrTimeout.New("handler timeout after "+timeout.String()))
+			}
+		})
+	}
+}
+
+// RecoveredPanic the panic recovered from the other goroutine, re-panicked with the original stack
+// Recovery reports the Value and the Stack of it
+type RecoveredPanic struct {
+	Value interface{}
+	Stack []byte
+}
+
+func (p *RecoveredPanic) String() string {
+	return fmt.Sprintf("%v", p.Value)
+}
+
+// timeoutWriter hold the header until the handler wrote it, then stream the response to w
+type timeoutWriter struct {
+	mu          sync.Mutex
+	w           http.ResponseWriter
+	header      http.Header
+	wroteHeader bool
+	timedOut    bool
+}
+
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.header
+}
+
+func (tw *timeoutWriter) Write(b []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if !tw.wroteHeader {
+		tw.writeHeaderLocked(http.StatusOK)
+	}
+	return tw.w.Write(b)
+}
+
+func (tw *timeoutWriter) WriteHeader(status int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut || tw.wroteHeader {
+		return
+	}
+	tw.writeHeaderLocked(status)
+}
+
+// Flush flush the streamed response if the underlying writer support it
+func (tw *timeoutWriter) Flush() {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut || !tw.wroteHeader {
+		return
+	}
+	if f, ok := tw.w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// writeHeaderLocked commit the header to w, tw.mu should be locked
+func (tw *timeoutWriter) writeHeaderLocked(status int) {
+	tw.wroteHeader = true
+	dst := tw.w.Header()
+	for k, v := range tw.header {
+		dst[k] = v
+	}
+	tw.w.WriteHeader(status)
+}
@@ -0,0 +1,125 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/codeduckcloud/trinity-go/core/httpx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimeout_Finished(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, ok := r.Context().Deadline()
+		assert.True(t, ok)
+		w.Header().Set("X-Test", "yes")
+		w.WriteHeader(http.StatusCreated)
+		w.WriteHeader(http.StatusTeapot)
+		w.Write([]byte("hello"))
+	})
+	rr := httptest.NewRecorder()
+	Timeout(time.Second)(next).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	assert.Equal(t, "yes", rr.Header().Get("X-Test"))
+	assert.Equal(t, "hello", rr.Body.String())
+}
+
+func TestTimeout_Exceeded(t *testing.T) {
+	writeErr := make(chan error, 1)
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+		// the slow handler keeps running after the deadline
+		time.Sleep(20 * time.Millisecond)
+		w.Header().Set("X-Test", "yes")
+		_, err := w.Write([]byte("late"))
+		writeErr <- err
+	})
+	rr := httptest.NewRecorder()
+	Timeout(10*time.Millisecond)(next).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusGatewayTimeout, rr.Code)
+	var resp httpx.Response
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	assert.Equal(t, http.StatusGatewayTimeout, resp.Status)
+
+	assert.Equal(t, http.ErrHandlerTimeout, <-writeErr)
+	assert.Empty(t, rr.Header().Get("X-Test"))
+	assert.NotContains(t, rr.Body.String(), "late")
+}
+
+func TestTimeout_ExceededAfterCommitted(t *testing.T) {
+	writeErr := make(chan error, 1)
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Write([]byte("part1"))
+		<-r.Context().Done()
+		time.Sleep(20 * time.Millisecond)
+		_, err := w.Write([]byte("part2"))
+		writeErr <- err
+	})
+	rr := httptest.NewRecorder()
+	Timeout(10*time.Millisecond)(next).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.ErrHandlerTimeout, <-writeErr)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/octet-stream", rr.Header().Get("Content-Type"))
+	assert.Equal(t, "part1", rr.Body.String())
+}
+
+func TestTimeout_ClientGone(t *testing.T) {
+	handlerErr := make(chan error, 1)
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+		handlerErr <- r.Context().Err()
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+	rr := httptest.NewRecorder()
+	Timeout(time.Second)(next).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx))
+	assert.Equal(t, context.Canceled, <-handlerErr)
+	assert.False(t, rr.Flushed)
+	assert.Empty(t, rr.Body.String())
+}
+
+func TestTimeout_Panic(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+	rr := httptest.NewRecorder()
+	Recovery()(Timeout(time.Second)(next)).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+}
+
+func TestTimeout_PanicStack(t *testing.T) {
+	var info PanicInfo
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panicInHandler()
+	})
+	rr := httptest.NewRecorder()
+	Recovery(func(ctx context.Context, i PanicInfo) {
+		info = i
+	})(Timeout(time.Second)(next)).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	assert.Equal(t, "boom", info.Value)
+	assert.Contains(t, string(info.Stack), "panicInHandler")
+}
+
+func panicInHandler() {
+	panic("boom")
+}
+
+func TestTimeout_Disabled(t *testing.T) {
+	var hasDeadline bool
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, hasDeadline = r.Context().Deadline()
+	})
+	Timeout(0)(next).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.False(t, hasDeadline)
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Accept", httpx.MimeEventStream)
+	Timeout(time.Second)(next).ServeHTTP(httptest.NewRecorder(), r)
+	assert.True(t, hasDeadline)
+}
@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/codeduckcloud/trinity-go/core/httpx"
+	"github.com/codeduckcloud/trinity-go/core/tracex"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Tracing start the server span for each request, named by the method and the route pattern
+// the span context propagated by the client, e.g. the W3C traceparent header, is the parent of the span
+// the 5xx response marks the span as error
+func Tracing() func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := tracex.Extract(r.Context(), r.Header)
+			name := r.Method
+			attrs := []attribute.KeyValue{
+				semconv.HTTPRequestMethodKey.String(r.Method),
+				semconv.URLPath(r.URL.Path),
+				semconv.ClientAddress(httpx.ClientIP(r)),
+				semconv.UserAgentOriginal(r.UserAgent()),
+			}
+			if route := matchRoutePattern(r); route != UnmatchedRoute {
+				name += " " + route
+				attrs = append(attrs, semconv.HTTPRoute(route))
+			}
+			ctx, span := tracex.Start(ctx, name,
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(attrs...),
+			)
+			defer span.End()
+
+			ww := httpx.WrapResponseWriter(w)
+			next.ServeHTTP(ww, r.WithContext(ctx))
+
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+			if status >= http.StatusInternalServerError {
+				span.SetStatus(codes.Error, http.StatusText(status))
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/codeduckcloud/trinity-go/core/tracex"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func withInMemoryTracer(t *testing.T) *tracetest.InMemoryExporter {
+	tp, exporter := tracex.NewInMemoryProvider()
+	tracex.SetTracerProvider(tp)
+	t.Cleanup(func() {
+		tracex.SetTracerProvider(nil)
+	})
+	return exporter
+}
+
+func spanAttribute(span tracetest.SpanStub, key attribute.Key) attribute.Value {
+	for _, attr := range span.Attributes {
+		if attr.Key == key {
+			return attr.Value
+		}
+	}
+	return attribute.Value{}
+}
+
+func TestTracing(t *testing.T) {
+	exporter := withInMemoryTracer(t)
+	router := chi.NewRouter()
+	router.Use(Tracing())
+	var traceID string
+	router.Get("/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		traceID = tracex.TraceID(r.Context())
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := exporter.GetSpans()
+	assert.Len(t, spans, 1)
+	span := spans[0]
+	assert.Equal(t, "GET /users/{id}", span.Name)
+	assert.Equal(t, trace.SpanKindServer, span.SpanKind)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.SpanContext.TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", span.Parent.SpanID().String())
+	assert.True(t, span.Parent.IsRemote())
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", traceID)
+	assert.Equal(t, "/users/{id}", spanAttribute(span, "http.route").AsString())
+	assert.Equal(t, int64(http.StatusServiceUnavailable), spanAttribute(span, "http.response.status_code").AsInt64())
+	assert.Equal(t, codes.Error, span.Status.Code)
+}
+
+func TestTracing_Unmatched(t *testing.T) {
+	exporter := withInMemoryTracer(t)
+	router := chi.NewRouter()
+	router.Use(Tracing())
+	router.Get("/users/{id}", func(w http.ResponseWriter, r *http.Request) {})
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/unknown", nil))
+
+	spans := exporter.GetSpans()
+	assert.Len(t, spans, 1)
+	assert.Equal(t, "POST", spans[0].Name)
+	assert.False(t, spans[0].Parent.IsValid())
+	assert.Equal(t, int64(http.StatusNotFound), spanAttribute(spans[0], "http.response.status_code").AsInt64())
+	assert.Equal(t, codes.Unset, spans[0].Status.Code)
+}
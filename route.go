@@ -0,0 +1,47 @@
+package trinity
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/codeduckcloud/trinity-go/core/container"
+	"github.com/codeduckcloud/trinity-go/middleware"
+)
+
+// Route the route registered by RegisterController
+type Route struct {
+	Method      string
+	Path        string
+	Controller  container.InstanceName
+	FuncName    string
+	Raw         bool
+	Requirement middleware.Requirement
+}
+
+// Routes list the registered routes in the order of registration
+func (t *trinity) Routes() []Route {
+	res := make([]Route, len(t.routes))
+	copy(res, t.routes)
+	return res
+}
+
+// WriteRoutes write the routes as the markdown table, which could be included in the API docs
+func (t *trinity) WriteRoutes(w io.Writer) error {
+	if _, err := fmt.Fprintln(w, "| Method | Path | Handler | Authorization |"); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(w, "| --- | --- | --- | --- |"); err != nil {
+		return err
+	}
+	for _, route := range t.routes {
+		authorization := route.Requirement.String()
+		if authorization == "" {
+			authorization = "-"
+		}
+		if _, err := fmt.Fprintf(w, "| %v | %v | %v.%v | %v |\n", route.Method, route.Path, route.Controller, route.FuncName, strings.ReplaceAll(authorization, "|", "\\|")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
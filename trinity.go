@@ -2,10 +2,22 @@ package trinity
 
 import (
 	"context"
+	"time"
 
 	"github.com/codeduckcloud/trinity-go/core/container"
+	"github.com/codeduckcloud/trinity-go/core/httpx"
+	"github.com/codeduckcloud/trinity-go/core/logx"
+	"github.com/codeduckcloud/trinity-go/core/metrics"
+	"github.com/codeduckcloud/trinity-go/core/tracex"
+	"github.com/codeduckcloud/trinity-go/middleware"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	DefaultMetricsPath = "/metrics"
 )
 
 var (
@@ -15,11 +27,72 @@ var (
 type Config struct {
 	Mux          mux
 	InstanceType container.InstanceType
+	// Validator replace the validation engine of httpx.Parse
+	// default: go-playground validator
+	Validator httpx.Validator
+	// ValidationTagNameFunc the field name used in the validation error
+	// e.g. httpx.JSONTagName
+	ValidationTagNameFunc validator.TagNameFunc
+	// Validations the custom validation tags
+	Validations []Validation
+	// StructValidations the struct level validations
+	StructValidations []StructValidation
+	// ErrorMode decide how much error detail responded to the client
+	// default: httpx.ErrorModeDevelopment
+	ErrorMode httpx.ErrorMode
+	// Envelope the response format of the app, can be overridden by route with httpx.UseEnvelope
+	// e.g. httpx.ProblemEnvelope{} to respond the error as application/problem+json
+	// default: httpx.ResponseEnvelope
+	Envelope httpx.Envelope
+	// PolicyEvaluator check the requirement declared by RequestMap.WithRequirement
+	// default: middleware.DefaultPolicyEvaluator
+	PolicyEvaluator middleware.PolicyEvaluator
+	// PanicHooks report the panic recovered by middleware.Recovery, e.g. to the error tracker
+	PanicHooks []middleware.PanicHook
+	// TrustedProxies the proxies allowed to set X-Forwarded-For and X-Real-IP, the ip or the CIDR
+	// the client ip is the remote address if not set, see httpx.ClientIP
+	TrustedProxies []string
+	// AccessLog the config of the access log, the access log is enabled by default
+	AccessLog middleware.AccessLogConfig
+	// DisableAccessLog disable the access log
+	DisableAccessLog bool
+	// Metrics collect the http and the container metrics and expose them in the prometheus text format
+	Metrics bool
+	// MetricsPath the path of the metrics endpoint
+	// default: DefaultMetricsPath
+	MetricsPath string
+	// MetricsRegistry the registry of the metrics
+	// default: metrics.DefaultRegistry
+	MetricsRegistry *metrics.Registry
+	// Tracing start the OpenTelemetry span for each request, with the W3C traceparent propagated
+	Tracing bool
+	// TracerProvider the tracer provider of the spans created by trinity
+	// default: the otel global tracer provider
+	TracerProvider trace.TracerProvider
+	// CORS enable CORS for all the routes registered by RegisterController, can be overridden by route with RequestMap.WithCORS
+	// the preflight requests are handled automatically
+	CORS *middleware.CORSConfig
+	// RequestTimeout the deadline of the handlers registered by RegisterController, can be overridden by route with RequestMap.WithTimeout
+	// e.ErrTimeout is responded when the deadline exceeded, see middleware.Timeout
+	// the websocket and the event stream handlers are not limited, see httpx.IsLongLived
+	// default: 0, no timeout
+	RequestTimeout time.Duration
+	// ReadTimeout the max duration of reading the entire request, see http.Server
+	ReadTimeout time.Duration
+	// ReadHeaderTimeout the max duration of reading the request headers, see http.Server
+	ReadHeaderTimeout time.Duration
+	// WriteTimeout the max duration before the response write timed out, see http.Server
+	// it limits the event stream as well, keep it 0 if the event stream is used
+	WriteTimeout time.Duration
+	// IdleTimeout the max duration to wait for the next request when keep-alive, see http.Server
+	IdleTimeout time.Duration
 }
 
 type trinity struct {
 	mux
 	container *container.Container
+	config    Config
+	routes    []Route
 }
 
 func New(ctx context.Context, c ...Config) *trinity {
@@ -36,11 +109,39 @@ func New(ctx context.Context, c ...Config) *trinity {
 			InstanceType: container.Singleton,
 		})
 	}
+	if c[0].MetricsPath == "" {
+		c[0].MetricsPath = DefaultMetricsPath
+	}
+	if c[0].MetricsRegistry == nil {
+		c[0].MetricsRegistry = metrics.DefaultRegistry()
+	}
+	if c[0].ErrorMode != "" {
+		httpx.SetErrorMode(c[0].ErrorMode)
+	}
+	if c[0].Envelope != nil {
+		httpx.SetEnvelope(c[0].Envelope)
+	}
+	if len(c[0].TrustedProxies) > 0 {
+		if err := httpx.SetTrustedProxies(c[0].TrustedProxies...); err != nil {
+			logx.FromCtx(ctx).Fatalf("%-8v %-10v %-7v, err: %v", "config", "proxies", "failed", err)
+		}
+	}
+	if c[0].TracerProvider != nil {
+		tracex.SetTracerProvider(c[0].TracerProvider)
+	}
+	if c[0].PolicyEvaluator != nil {
+		middleware.SetPolicyEvaluator(c[0].PolicyEvaluator)
+	}
 	ins := &trinity{
 		mux:       c[0].Mux,
 		container: container.NewContainer(),
+		config:    c[0],
+	}
+	if c[0].Metrics {
+		ins.container.SetObserver(metrics.NewContainerObserver(c[0].MetricsRegistry))
 	}
 	ins.initInstance(ctx)
+	ins.initValidator(ctx, c[0])
 	ins.diRouter(ctx)
 	return ins
 }
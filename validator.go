@@ -0,0 +1,59 @@
+package trinity
+
+import (
+	"context"
+
+	"github.com/codeduckcloud/trinity-go/core/container"
+	"github.com/codeduckcloud/trinity-go/core/httpx"
+	"github.com/codeduckcloud/trinity-go/core/logx"
+	"github.com/go-playground/validator/v10"
+)
+
+// Validation the custom validation tag registered to the httpx validator
+// New will be called after the instance self check,
+// so the validation func could get the dependency from the container
+// e.g.
+//
+//	trinity.Validation{
+//		Tag: "tenant_exists",
+//		New: func(ctx context.Context, c *container.Container) validator.FuncCtx {
+//			repo := c.GetInstance(ctx, "TenantRepository", map[container.InstanceName]interface{}{}).(TenantRepository)
+//			return func(ctx context.Context, fl validator.FieldLevel) bool {
+//				return repo.Exist(ctx, fl.Field().String())
+//			}
+//		},
+//	}
+type Validation struct {
+	Tag                      string
+	New                      func(ctx context.Context, c *container.Container) validator.FuncCtx
+	CallValidationEvenIfNull bool
+}
+
+// StructValidation the struct level validation registered to the httpx validator
+// it is used for the cross field validation
+type StructValidation struct {
+	New   func(ctx context.Context, c *container.Container) validator.StructLevelFuncCtx
+	Types []interface{}
+}
+
+func (t *trinity) initValidator(ctx context.Context, c Config) {
+	if c.Validator != nil {
+		httpx.SetValidator(c.Validator)
+	}
+	if c.ValidationTagNameFunc != nil {
+		if err := httpx.RegisterTagNameFunc(c.ValidationTagNameFunc); err != nil {
+			logx.FromCtx(ctx).Fatalf("%-8v %-10v %-7v, err: %v", "validator", "tag-name", "failed", err)
+		}
+	}
+	for _, v := range c.Validations {
+		if err := httpx.RegisterValidation(v.Tag, v.New(ctx, t.container), v.CallValidationEvenIfNull); err != nil {
+			logx.FromCtx(ctx).Fatalf("%-8v %-10v %-7v => %v, err: %v", "validator", "register", "failed", v.Tag, err)
+		}
+		logx.FromCtx(ctx).Infof("%-8v %-10v %-7v => %v ", "validator", "register", "success", v.Tag)
+	}
+	for _, v := range c.StructValidations {
+		if err := httpx.RegisterStructValidation(v.New(ctx, t.container), v.Types...); err != nil {
+			logx.FromCtx(ctx).Fatalf("%-8v %-10v %-7v, err: %v", "validator", "register", "failed", err)
+		}
+	}
+}